@@ -2,34 +2,148 @@ package cmd
 
 import (
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/bjatkin/bear"
 	"github.com/spf13/cobra"
 
+	"github.com/bjatkin/blow-k/internal/check"
 	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/gen"
+	"github.com/bjatkin/blow-k/internal/load"
+)
+
+// stdout is the output name that writes the script to stdout
+const stdout = "-"
+
+var (
+	buildOutput    string
+	buildMaxErrors int
 )
 
 func init() {
+	buildCmd.Flags().StringVarP(&buildOutput, "output", "o", "",
+		"write the script to this file or directory, use - for stdout")
+	buildCmd.Flags().IntVar(&buildMaxErrors, "max-errors", 10,
+		"stop reporting errors after this many, use 0 to report every error")
 	rootCmd.AddCommand(buildCmd)
 }
 
 var buildCmd = &cobra.Command{
-	Use:   "build [source file]",
+	Use:   "build [source files]",
 	Short: "transpile blowK source code into bash",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		srcFile := args[0]
-		_, err := ioutil.ReadFile(srcFile)
-		if err != nil {
-			return bear.Wrap(err,
-				bear.WithErrType(errors.FileNotFound),
+	Long: `transpile blowK source code into bash
+
+by default each script is written next to its source file with a .sh extension.
+if --output is a directory (or there are multiple source files) each script is
+written into that directory instead. use --output - to write to stdout.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		if len(args) > 1 && buildOutput == stdout {
+			return errors.New(
+				bear.WithErrType(errors.InvalidArgs),
 				bear.WithExitCode(errors.BuildFailed),
-				bear.WithTag("src name", srcFile),
+				bear.WithLabels("only one source file can be written to stdout"),
 			)
 		}
 
-		// TODO: build the code
-
 		return nil
 	},
+	RunE: reported(func(cmd *cobra.Command, args []string, rep *reporter) error {
+		toDir := len(args) > 1 || strings.HasSuffix(buildOutput, string(filepath.Separator))
+		if info, err := os.Stat(buildOutput); err == nil && info.IsDir() {
+			toDir = true
+		}
+
+		for _, srcFile := range args {
+			_, err := ioutil.ReadFile(srcFile)
+			if err != nil {
+				return bear.Wrap(err,
+					bear.WithErrType(errors.FileNotFound),
+					bear.WithExitCode(errors.BuildFailed),
+					bear.WithTag("src name", srcFile),
+				)
+			}
+
+			script, err := build(srcFile, rep)
+			if err != nil {
+				return rep.diagnose(err, buildMaxErrors)
+			}
+
+			if buildOutput == stdout {
+				_, err = cmd.OutOrStdout().Write(script)
+				return err
+			}
+
+			outFile := outputPath(srcFile, buildOutput, toDir)
+			if err := writeScript(outFile, script); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}),
+}
+
+// build runs the full compiler pipeline on the source file and returns the bash script
+// warnings about the source code are reported to rep
+func build(srcFile string, rep *reporter) ([]byte, error) {
+	root, warnings, err := load.NewClient().Load(srcFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range warnings {
+		rep.warn(w)
+	}
+
+	if err := check.NewClient().Check(root); err != nil {
+		return nil, err
+	}
+
+	return gen.NewClient().Generate(root)
+}
+
+// outputPath returns the path the script for srcFile should be written to
+func outputPath(srcFile, output string, toDir bool) string {
+	name := strings.TrimSuffix(filepath.Base(srcFile), filepath.Ext(srcFile)) + ".sh"
+
+	switch {
+	case output == "":
+		return filepath.Join(filepath.Dir(srcFile), name)
+	case toDir:
+		return filepath.Join(output, name)
+	default:
+		return output
+	}
+}
+
+// writeScript writes the script to the out file and marks it as executable
+func writeScript(outFile string, script []byte) error {
+	if err := os.MkdirAll(filepath.Dir(outFile), 0755); err != nil {
+		return bear.Wrap(err,
+			bear.WithExitCode(errors.BuildFailed),
+			bear.WithTag("out name", outFile),
+		)
+	}
+
+	if err := os.WriteFile(outFile, script, 0755); err != nil {
+		return bear.Wrap(err,
+			bear.WithExitCode(errors.BuildFailed),
+			bear.WithTag("out name", outFile),
+		)
+	}
+
+	// WriteFile only sets the mode for new files so make sure existing files are executable too
+	if err := os.Chmod(outFile, 0755); err != nil {
+		return bear.Wrap(err,
+			bear.WithExitCode(errors.BuildFailed),
+			bear.WithTag("out name", outFile),
+		)
+	}
+
+	return nil
 }
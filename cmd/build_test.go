@@ -0,0 +1,40 @@
+package cmd
+
+import "testing"
+
+func Test_outputPath(t *testing.T) {
+	type args struct {
+		srcFile string
+		output  string
+		toDir   bool
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			"next to source",
+			args{srcFile: "scripts/hello.bk"},
+			"scripts/hello.sh",
+		},
+		{
+			"into a directory",
+			args{srcFile: "scripts/hello.bk", output: "dist", toDir: true},
+			"dist/hello.sh",
+		},
+		{
+			"explicit file",
+			args{srcFile: "scripts/hello.bk", output: "bin/hello"},
+			"bin/hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := outputPath(tt.args.srcFile, tt.args.output, tt.args.toDir); got != tt.want {
+				t.Errorf("outputPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
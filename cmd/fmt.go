@@ -1,18 +1,139 @@
 package cmd
 
-import "github.com/spf13/cobra"
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bjatkin/bear"
+	"github.com/spf13/cobra"
+
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/format"
+	"github.com/bjatkin/blow-k/internal/lex"
+	"github.com/bjatkin/blow-k/internal/tok"
+)
+
+var (
+	fmtList  bool
+	fmtDiff  bool
+	fmtCheck bool
+)
 
 func init() {
+	fmtCmd.Flags().BoolVarP(&fmtList, "list", "l", false, "list files whose formatting differs from blowk fmt's")
+	fmtCmd.Flags().BoolVarP(&fmtDiff, "diff", "d", false, "display diffs instead of rewriting files")
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "exit with a non-zero status if any file is not formatted")
 	rootCmd.AddCommand(fmtCmd)
 }
 
 var fmtCmd = &cobra.Command{
-	Use:   "fmt [source file]",
+	Use:   "fmt [source files or directories]",
 	Short: "format and re-write the source file",
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// TODO: fmt the code
+	Long: `format and re-write the source file
+
+directories are searched recursively for .bk files. when -l, -d or --check
+are set the files are not re-written.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: reported(func(cmd *cobra.Command, args []string, rep *reporter) error {
+		srcFiles, err := sourceFiles(args)
+		if err != nil {
+			return err
+		}
+
+		var unformatted []string
+		for _, srcFile := range srcFiles {
+			src, err := os.ReadFile(srcFile)
+			if err != nil {
+				return bear.Wrap(err,
+					bear.WithErrType(errors.FileNotFound),
+					bear.WithExitCode(errors.FmtFailed),
+					bear.WithTag("src name", srcFile),
+				)
+			}
+
+			tokens, err := tok.NewClient().Tokenize(srcFile)
+			if err != nil {
+				return err
+			}
+
+			lexTokens, err := lex.NewClient().Lex(tokens)
+			if err != nil {
+				return rep.diagnose(err, 0)
+			}
+
+			formatted := format.NewClient().Format(lexTokens)
+			if string(formatted) == string(src) {
+				continue
+			}
+			unformatted = append(unformatted, srcFile)
+
+			out := cmd.OutOrStdout()
+			if fmtList || fmtCheck {
+				fmt.Fprintln(out, srcFile)
+			}
+			if fmtDiff {
+				fmt.Fprint(out, format.Diff(srcFile, src, formatted))
+			}
+			if fmtList || fmtDiff || fmtCheck {
+				continue
+			}
+
+			if err := os.WriteFile(srcFile, formatted, 0644); err != nil {
+				return bear.Wrap(err,
+					bear.WithExitCode(errors.FmtFailed),
+					bear.WithTag("src name", srcFile),
+				)
+			}
+		}
+
+		if fmtCheck && len(unformatted) > 0 {
+			return errors.New(
+				bear.WithErrType(errors.Unformatted),
+				bear.WithExitCode(errors.FmtFailed),
+				bear.WithTag("files", unformatted),
+			)
+		}
 
 		return nil
-	},
+	}),
+}
+
+// sourceFiles expands any directories in paths into the .bk files they contain
+func sourceFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, bear.Wrap(err,
+				bear.WithErrType(errors.FileNotFound),
+				bear.WithExitCode(errors.FmtFailed),
+				bear.WithTag("src name", path),
+			)
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(path) == ".bk" {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, bear.Wrap(err,
+				bear.WithExitCode(errors.FmtFailed),
+				bear.WithTag("src name", path),
+			)
+		}
+	}
+
+	return files, nil
 }
@@ -2,18 +2,156 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
 
+	"github.com/bjatkin/bear"
 	"github.com/spf13/cobra"
+
+	"github.com/bjatkin/blow-k/internal/check"
+	"github.com/bjatkin/blow-k/internal/errors"
 )
 
+var diagnosticsFormat string
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&diagnosticsFormat, "diagnostics-format", errors.TextFormat,
+		"format of the problems found in source code ("+strings.Join(errors.Formats, "|")+"), json and sarif are written to stderr when the command finishes")
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "blowk <command> [arguments]",
 	Short: "blowk is the tool for managing blowK source code",
+	// errors are printed by Execute so they can be formatted
+	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		known := false
+		for _, format := range errors.Formats {
+			known = known || diagnosticsFormat == format
+		}
+		if !known {
+			return errors.New(
+				bear.WithErrType(errors.InvalidArgs),
+				bear.WithExitCode(errors.BuildFailed),
+				bear.WithLabels("unknown diagnostics format "+diagnosticsFormat+", use one of "+strings.Join(errors.Formats, ", ")),
+			)
+		}
+
+		// the arguments and flags are valid so later errors are not caused by how blowk was called
+		cmd.SilenceUsage = true
+		return nil
+	},
 }
 
+// Execute runs the blowk command and exits with the exit code attached to the error if it fails
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println("blowk encountered an error, exiting")
-		panic(err)
+	if code := execute(rootCmd); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// execute runs the command and returns the exit code for the process
+// errors are written to stderr unless they were already reported as diagnostics
+func execute(root *cobra.Command) int {
+	cmd, err := root.ExecuteC()
+	if err == nil {
+		return 0
+	}
+
+	if r, ok := err.(reportedError); ok {
+		return errors.ExitCode(r.error)
+	}
+
+	for _, e := range errors.Errors(err) {
+		fmt.Fprintln(cmd.ErrOrStderr(), "blowk: "+errors.Message(e))
+	}
+
+	return errors.ExitCode(err)
+}
+
+// reportedError is an error that has already been written to stderr, execute only uses it for the exit code
+type reportedError struct {
+	error
+}
+
+// reporter writes the problems found in source code in the format set by --diagnostics-format
+// text is written as soon as a problem is found, json and sarif are collected and written together by flush
+type reporter struct {
+	out   io.Writer
+	diags []errors.Diagnostic
+}
+
+// reported runs a command with a reporter, the reporter is flushed after the command runs even if it fails
+func reported(run func(cmd *cobra.Command, args []string, rep *reporter) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		rep := &reporter{out: cmd.ErrOrStderr()}
+		err := run(cmd, args, rep)
+		if ferr := rep.flush(); ferr != nil && err == nil {
+			return ferr
+		}
+
+		return err
+	}
+}
+
+// warn reports a warning about the source code
+func (r *reporter) warn(w check.Warning) {
+	if diagnosticsFormat == errors.TextFormat {
+		fmt.Fprintln(r.out, w)
+		return
+	}
+
+	r.diags = append(r.diags, w.Diagnostic())
+}
+
+// diagnose reports each error along with the source code that caused it, at most limit errors are reported
+// errors that point at a source file are returned as a reportedError so execute does not print them again
+func (r *reporter) diagnose(err error, limit int) error {
+	diags := errors.NewCollector(limit)
+	diags.Add(err)
+	err = diags.Err()
+	errs := errors.Errors(err)
+	if _, ok := errors.Diagnose(errs[0]); !ok {
+		return err
+	}
+
+	for _, e := range errs {
+		d, ok := errors.Diagnose(e)
+		switch {
+		case !ok:
+			fmt.Fprintln(r.out, e)
+		case diagnosticsFormat != errors.TextFormat:
+			r.diags = append(r.diags, d)
+		default:
+			if werr := d.Render(r.out); werr != nil {
+				return werr
+			}
+		}
+	}
+	if diagnosticsFormat != errors.TextFormat {
+		return reportedError{err}
+	}
+
+	switch {
+	case diags.Truncated():
+		fmt.Fprintf(r.out, "too many errors, only the first %d are shown\n", diags.Len())
+	case len(errs) > 1:
+		fmt.Fprintf(r.out, "%d errors\n", len(errs))
+	}
+
+	return reportedError{err}
+}
+
+// flush writes every collected diagnostic, a document is always written for json and sarif
+// so tools can tell that the source code had no problems
+func (r *reporter) flush() error {
+	switch diagnosticsFormat {
+	case errors.JSONFormat:
+		return errors.WriteJSON(r.out, r.diags)
+	case errors.SARIFFormat:
+		return errors.WriteSARIF(r.out, r.diags)
+	default:
+		return nil
 	}
 }
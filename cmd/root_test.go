@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/blow-k/internal/errors"
+)
+
+func Test_execute(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lex.bk":   "main:(): {\n    a :: @\n}\n",
+		"parse.bk": "main:(): {\n    a :: 1 +\n}\n",
+		"lib.bk":   "pt :: < x:int >\n",
+		"fmt.bk":   "x::1",
+		"check.bk": "import pt from \"lib.bk\"\nmain:(): {\n    p:pt\n    a:int: p\n}\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatalf("execute() failed to write src file %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		want       int
+		wantStderr string
+		wantUsage  bool
+	}{
+		{
+			"missing source file",
+			[]string{"build", filepath.Join(dir, "missing.bk")},
+			errors.BuildFailed,
+			"blowk: File Not Found: open " + filepath.Join(dir, "missing.bk") + ": no such file or directory",
+			false,
+		},
+		{
+			"lexer errors",
+			[]string{"build", filepath.Join(dir, "lex.bk")},
+			errors.LexerFailed,
+			"error[BK0012]: invalid token @",
+			false,
+		},
+		{
+			"syntax errors",
+			[]string{"build", filepath.Join(dir, "parse.bk")},
+			errors.ASTFailed,
+			"error[BK0001]: expected an expression",
+			false,
+		},
+		{
+			"imported names in errors",
+			[]string{"build", filepath.Join(dir, "check.bk")},
+			errors.CheckFailed,
+			"error[BK0006]: cannot use pt value as int",
+			false,
+		},
+		{
+			"unformatted files",
+			[]string{"fmt", "--check", filepath.Join(dir, "fmt.bk")},
+			errors.FmtFailed,
+			"blowk: Unformatted Source",
+			false,
+		},
+		{
+			"unknown command",
+			[]string{"bogus"},
+			errors.BuildFailed,
+			`blowk: unknown command "bogus" for "blowk"`,
+			false,
+		},
+		{
+			"unknown flag",
+			[]string{"--bogus"},
+			errors.BuildFailed,
+			"blowk: unknown flag: --bogus",
+			true,
+		},
+		{
+			"missing arguments",
+			[]string{"build"},
+			errors.BuildFailed,
+			"blowk: requires at least 1 arg(s), only received 0",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// commands silence their usage once they run so it has to be reset between runs
+			for _, cmd := range rootCmd.Commands() {
+				cmd.SilenceUsage = false
+			}
+
+			var stdout, stderr strings.Builder
+			rootCmd.SetArgs(tt.args)
+			rootCmd.SetOut(&stdout)
+			rootCmd.SetErr(&stderr)
+
+			if got := execute(rootCmd); got != tt.want {
+				t.Errorf("execute() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("execute() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+			// cobra writes the usage to the out writer when one is set
+			if usage := strings.Contains(stdout.String(), "Usage:"); usage != tt.wantUsage {
+				t.Errorf("execute() printed usage = %v, want %v", usage, tt.wantUsage)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/bjatkin/bear"
+	"github.com/spf13/cobra"
+
+	"github.com/bjatkin/blow-k/internal/errors"
+)
+
+var runMaxErrors int
+
+func init() {
+	runCmd.Flags().IntVar(&runMaxErrors, "max-errors", 10,
+		"stop reporting errors after this many, use 0 to report every error")
+	rootCmd.AddCommand(runCmd)
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run source_file [-- arguments]",
+	Short: "transpile blowK source code and run it",
+	Long: `transpile blowK source code and run it
+
+the script is written to a temp file and run with bash. arguments after the
+source file are passed to main, use -- before any arguments that start with -.
+stdin, stdout and stderr are passed through to the script and blowk exits with
+the script's exit status.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		if dash := cmd.ArgsLenAtDash(); dash == 0 || dash > 1 {
+			return errors.New(
+				bear.WithErrType(errors.InvalidArgs),
+				bear.WithExitCode(errors.RunFailed),
+				bear.WithLabels("run takes exactly one source file before --"),
+			)
+		}
+
+		return nil
+	},
+	RunE: reported(func(cmd *cobra.Command, args []string, rep *reporter) error {
+		srcFile := args[0]
+		if _, err := os.Stat(srcFile); err != nil {
+			return bear.Wrap(err,
+				bear.WithErrType(errors.FileNotFound),
+				bear.WithExitCode(errors.RunFailed),
+				bear.WithTag("src name", srcFile),
+			)
+		}
+
+		script, err := build(srcFile, rep)
+		if err != nil {
+			return rep.diagnose(err, runMaxErrors)
+		}
+
+		status, err := runScript(script, args[1:], cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+		if err != nil {
+			return err
+		}
+		if status != 0 {
+			// the script has already written its own errors so blowk only passes on the exit status
+			return reportedError{errors.New(
+				bear.WithExitCode(status),
+				bear.WithLabels("script exited with a non-zero status"),
+			)}
+		}
+
+		return nil
+	}),
+}
+
+// runScript writes the script to a temp file and runs it with bash, the temp file is removed once the script exits
+// the exit status of the script is returned, err is only set if the script could not be run
+func runScript(script []byte, args []string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		return 0, bear.Wrap(err,
+			bear.WithExitCode(errors.RunFailed),
+			bear.WithLabels("bash is needed to run blowK scripts"),
+		)
+	}
+
+	tmp, err := os.CreateTemp("", "blowk-*.sh")
+	if err != nil {
+		return 0, bear.Wrap(err, bear.WithExitCode(errors.RunFailed))
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(script)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return 0, bear.Wrap(err,
+			bear.WithExitCode(errors.RunFailed),
+			bear.WithTag("out name", tmp.Name()),
+		)
+	}
+
+	run := exec.Command(bash, append([]string{tmp.Name()}, args...)...)
+	run.Stdin = stdin
+	run.Stdout = stdout
+	run.Stderr = stderr
+
+	// interrupts from the terminal go to the script as well, blowk waits for the script to handle them
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	err = run.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		// scripts killed by a signal exit the way bash reports them, with 128 plus the signal number
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			return 128 + int(ws.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 0, bear.Wrap(err, bear.WithExitCode(errors.RunFailed))
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func Test_runScript(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		args       []string
+		stdin      string
+		want       int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			"arguments",
+			"echo \"$#\" \"$1\" \"$2\"\n",
+			[]string{"-x", "two words"},
+			"",
+			0,
+			"2 -x two words\n",
+			"",
+		},
+		{
+			"stdio",
+			"cat\necho oops >&2\n",
+			nil,
+			"from stdin\n",
+			0,
+			"from stdin\n",
+			"oops\n",
+		},
+		{
+			"exit status",
+			"exit 3\n",
+			nil,
+			"",
+			3,
+			"",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			got, err := runScript([]byte(tt.script), tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+			if err != nil {
+				t.Fatalf("runScript() unexpected error %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("runScript() = %v, want %v", got, tt.want)
+			}
+			if stdout.String() != tt.wantStdout {
+				t.Errorf("runScript() stdout = %q, want %q", stdout.String(), tt.wantStdout)
+			}
+			if stderr.String() != tt.wantStderr {
+				t.Errorf("runScript() stderr = %q, want %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// Client is a type checking client that verifies a lang.Node tree before any bash is generated
+type Client struct{}
+
+// NewClient creates a new default check.Client
+func NewClient() *Client {
+	return &Client{}
+}
+
+// Check resolves every identifier in a blowK program and checks that every value matches the type it is used as
+// checking continues with the next statement after an error so every error in the program is reported at once
+func (c *Client) Check(node lang.Node) error {
+	root, ok := node.(*lang.Root)
+	if !ok {
+		return invalidNode(node)
+	}
+
+	ch := &checker{
+		imports: make(map[string]bool),
+		scope:   lang.NewTypeScope(nil),
+		diags:   errors.NewCollector(0),
+	}
+
+	for _, node := range root.Imports {
+		imp, ok := node.(*lang.Import)
+		if !ok {
+			return invalidNode(node)
+		}
+		ch.imports[imp.LocalName()] = true
+	}
+
+	// struct types, functions and globals can be used anywhere in the program so they are declared first
+	for _, node := range root.Expressions {
+		if v, ok := node.(*lang.Var); ok && v.IsTypeDecl() {
+			ch.scope.DeclareType(v.Name, v.Default.(*lang.StructType))
+		}
+	}
+
+	for _, node := range root.Expressions {
+		if v, ok := node.(*lang.Var); ok && v.IsFuncDecl() {
+			ch.report(ch.validType(v.Default.(*lang.Func).Type))
+			ch.scope.Declare(v.Name, v.Default.(*lang.Func).Type)
+		}
+	}
+
+	for _, node := range root.Expressions {
+		v, ok := node.(*lang.Var)
+		if !ok {
+			ch.report(checkError(node, "only imports and declarations are allowed at the top level"))
+			continue
+		}
+
+		switch {
+		case v.IsTypeDecl():
+			ch.report(ch.checkStructType(v.Default.(*lang.StructType)))
+		case !v.IsFuncDecl():
+			ch.report(ch.checkVar(v))
+		}
+	}
+
+	for _, node := range root.Expressions {
+		v, ok := node.(*lang.Var)
+		switch {
+		case ok && v.IsFuncDecl():
+			ch.report(ch.checkFunc(v.Default.(*lang.Func), nil))
+		case ok && v.IsTypeDecl():
+			ch.report(ch.checkMethods(v.Name, v.Default.(*lang.StructType)))
+		}
+	}
+
+	if root.Main != nil {
+		fn, ok := root.Main.Default.(*lang.Func)
+		switch {
+		case !ok:
+			ch.report(checkError(root.Main, "main must be a function"))
+		case len(fn.Type.Returns) > 0:
+			ch.report(checkError(root.Main, "main can not return values"))
+		default:
+			ch.report(ch.checkFunc(fn, nil))
+		}
+	}
+
+	return ch.diags.Err()
+}
+
+// checker holds the state for a single call to Check
+type checker struct {
+	// imports are the names commands are imported as
+	imports map[string]bool
+
+	// scope is the inner most scope of declared variables
+	scope *lang.TypeScope
+
+	// fn is the signature of the function currently being checked, it is nil at the top level
+	fn *lang.FuncType
+
+	// diags collects the error of every statement that failed to check
+	diags *errors.Collector
+}
+
+// errSkip is returned for values that use a declaration that failed to check
+// the declaration has already been reported so errSkip is never reported itself
+var errSkip = errors.New(bear.WithLabels("skipped"))
+
+// report adds the error to the diagnostics unless it is nil or errSkip
+func (c *checker) report(err error) {
+	if err != nil && err != errSkip {
+		c.diags.Add(err)
+	}
+}
+
+// pushScope starts a new inner scope
+func (c *checker) pushScope() {
+	c.scope = lang.NewTypeScope(c.scope)
+}
+
+// popScope returns to the parent scope
+func (c *checker) popScope() {
+	c.scope = c.scope.Parent
+}
+
+// checkError returns a type error positioned at the node, opts can replace the code or add a hint
+func checkError(node lang.Node, msg string, opts ...bear.ErrOption) error {
+	tok := node.Pos()
+	return errors.New(append([]bear.ErrOption{
+		bear.WithErrType(errors.SyntaxError),
+		bear.WithExitCode(errors.CheckFailed),
+		bear.WithLabels(msg),
+		errors.WithCode(errors.TypeCode),
+		errors.At(tok.FileName, tok.LineNumber, tok.ColNumber, tok.Value),
+	}, opts...)...)
+}
+
+// undefined returns an error for a reference to an undeclared identifier
+func undefined(ident *lang.Ident) error {
+	return checkError(ident, "undefined: "+ident.SourceName(),
+		errors.WithCode(errors.UndefinedCode),
+		undefinedHint(ident.Name),
+	)
+}
+
+// undefinedHint returns the hint for a name that is not declared
+func undefinedHint(name string) bear.ErrOption {
+	return errors.WithHint("check the spelling of " + name + " or declare it before it is used")
+}
+
+// unknownTypeHint returns the hint for a type name that is not declared
+func unknownTypeHint(name string) bear.ErrOption {
+	return errors.WithHint("declare the type with " + name + " :: < field:type >")
+}
+
+// mismatch returns an error for a value that can not be used as the wanted type
+func mismatch(node lang.Node, got, want lang.Node) error {
+	return checkError(node, fmt.Sprintf("cannot use %s value as %s", typeString(got), typeString(want)),
+		errors.WithCode(errors.MismatchCode),
+	)
+}
+
+// notImported returns an error for a command that is used without being imported
+func notImported(ident *lang.Ident) error {
+	return checkError(ident, ident.SourceName()+" is not an imported command",
+		errors.WithCode(errors.NotImportedCode),
+		errors.WithHint("add import "+ident.SourceName()+" to the top of the file"),
+	)
+}
+
+// missingReturn returns an error for a function with return types that can reach the end of its body
+func missingReturn(body *lang.Block) error {
+	return checkError(body, "missing return",
+		errors.WithCode(errors.MissingReturnCode),
+		errors.WithHint("every path through a function with return types must end with a return statement"),
+	)
+}
+
+// invalidNode returns an error for a node the checker does not support
+func invalidNode(node lang.Node) error {
+	return errors.New(
+		bear.WithErrType(errors.InvalidNode),
+		bear.WithExitCode(errors.CheckFailed),
+		bear.WithTag("node type", fmt.Sprintf("%T", node)),
+	)
+}
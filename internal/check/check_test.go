@@ -0,0 +1,300 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lang"
+	"github.com/bjatkin/blow-k/internal/lex"
+	"github.com/bjatkin/blow-k/internal/tok"
+)
+
+// buildSrc runs the src code through the tokenizer, lexer and parser
+func buildSrc(t *testing.T, src string) lang.Node {
+	srcFile := filepath.Join(t.TempDir(), "src.bk")
+	if err := os.WriteFile(srcFile, []byte(src), 0644); err != nil {
+		t.Fatalf("buildSrc() failed to write src file %v", err)
+	}
+
+	tokens, err := tok.NewClient().Tokenize(srcFile)
+	if err != nil {
+		t.Fatalf("buildSrc() unexpected error %v", err)
+	}
+
+	lexTokens, err := lex.NewClient().Lex(tokens)
+	if err != nil {
+		t.Fatalf("buildSrc() unexpected error %v", err)
+	}
+
+	root, err := lang.NewClient().Build(lexTokens)
+	if err != nil {
+		t.Fatalf("buildSrc() unexpected error %v", err)
+	}
+
+	return root
+}
+
+func TestClient_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			"inferred types",
+			"import echo\na :: 1 + 2\nb :: \"hi\" + \"!\"\nmain:(): {\n    c :: a < 4\n    $echo[b]\n}\n",
+			"",
+		},
+		{
+			"mismatched declaration",
+			"a:int: \"hi\"\n",
+			"cannot use string value as int",
+		},
+		{
+			"mismatched assignment",
+			"main:(): {\n    a:: [1, 2]\n    a[0]: true\n}\n",
+			"cannot use bool value as int",
+		},
+		{
+			"undefined identifier",
+			"main:(): {\n    a :: b\n}\n",
+			"undefined: b",
+		},
+		{
+			"unknown type",
+			"a:v2\n",
+			"unknown type v2",
+		},
+		{
+			"struct arrays",
+			"v2 :: < x, y:int >\nmain:(): {\n    a:[]v2: [{x: 1, y: 2}]\n}\n",
+			"arrays of v2 are not supported",
+		},
+		{
+			"nested fn arrays",
+			"a:[][]fn(int)\n",
+			"arrays of fn(int) are not supported",
+		},
+		{
+			"array lengths",
+			"main:(args:[]string): {\n    a:[][]int: [[1], [2, 3]]\n    n :: len(args) + len(a) + len(a[1])\n    n++\n}\n",
+			"",
+		},
+		{
+			"length of a string",
+			"main:(): {\n    n :: len(\"hi\")\n    n++\n}\n",
+			"string values have no length",
+		},
+		{
+			"len is reserved",
+			"len :: 1\n",
+			"len is reserved for the length of an array",
+		},
+		{
+			"function arguments",
+			"add:(a, b:int)<int>: {\n    return a + b\n}\nmain:(): {\n    c :: add(1, \"2\")\n}\n",
+			"cannot use string value as int",
+		},
+		{
+			"argument count",
+			"add:(a, b:int)<int>: {\n    return a + b\n}\nmain:(): {\n    c :: add(1)\n}\n",
+			"expected 2 arguments but 1 were given",
+		},
+		{
+			"return values",
+			"name:()<string>: {\n    return 5\n}\n",
+			"cannot use int value as string",
+		},
+		{
+			"struct literals",
+			"v2 :: < x, y:int >\nmain:(): {\n    a:v2: { x: 1, z: 2 }\n}\n",
+			"unknown field z",
+		},
+		{
+			"methods",
+			"v2 :: < x:int\n    add:(n:int): {\n        me.x : me.x + n\n    }\n>\nmain:(): {\n    a:v2\n    a.add(2)\n    a.sub(2)\n}\n",
+			"v2 has no method sub",
+		},
+		{
+			"conditions",
+			"main:(): {\n    if 1 {\n    }\n}\n",
+			"expected a bool condition, got int",
+		},
+		{
+			"comparisons",
+			"main:(): {\n    a :: 1 == \"1\"\n}\n",
+			"cannot compare int and string values",
+		},
+		{
+			"fn values",
+			"twice:(f fn(int)<int>, n:int)<int>: {\n    return f(f(n))\n}\nsay:(s:string)<string>: {\n    return s\n}\nmain:(): {\n    a :: twice(say, 1)\n}\n",
+			"cannot use fn(string)<string> value as fn(int)<int>",
+		},
+		{
+			"cmds",
+			"import ls\nmain:(): {\n    c:cmd: ls[\"-a\"]\n    c <- \"-l\"\n    c <- 1\n}\n",
+			"cannot append int value to cmd",
+		},
+		{
+			"missing return",
+			"f:(a:int)<int>: {\n    if a > 1 {\n        return 2\n    }\n}\n",
+			"missing return",
+		},
+		{
+			"every path returns",
+			"f:(a:int)<int>: {\n    if a > 1 {\n        return 2\n    } else {\n        return 3\n    }\n}\ng:()<int>: {\n    loop {\n        return 1\n    }\n}\n",
+			"",
+		},
+		{
+			"commands must be imported",
+			"main:(): {\n    $ls[]\n}\n",
+			"ls is not an imported command",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewClient().Check(buildSrc(t, tt.src))
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			if berr, ok := err.(*bear.Error); !ok || !berr.HasLabel(tt.wantErr) {
+				t.Errorf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClient_Resolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		want    []string
+		wantErr string
+	}{
+		{
+			"functions can be used before they are declared",
+			"main:(): {\n    say(\"hi\")\n}\nsay:(s:string): {\n    a :: s\n    a : \"yo\"\n}\n",
+			[]string{"src.bk:5:5: warning: declared and not used: a"},
+			"",
+		},
+		{
+			"unused imports",
+			"import echo\nimport grep as g\nmain:(): {\n    $echo[\"hi\"]\n}\n",
+			[]string{"src.bk:2:1: warning: imported and not used: g"},
+			"",
+		},
+		{
+			"shadowed names",
+			"import echo\nx :: 1\nmain:(x:string): {\n    if true {\n        x :: 2\n        $echo[x]\n    }\n}\n",
+			[]string{
+				"src.bk:3:7: warning: x shadows the declaration at line 2",
+				"src.bk:5:9: warning: x shadows the declaration at line 3",
+			},
+			"",
+		},
+		{
+			"closures and methods",
+			"v2 :: < x:int\n    add:(n:int)<fn()<int>>: {\n        return ()<int> {\n            return me.x + n\n        }\n    }\n>\n",
+			nil,
+			"",
+		},
+		{
+			"undefined names",
+			"main:(): {\n    if true {\n        a :: 1\n    }\n    b :: a\n}\n",
+			nil,
+			"undefined: a",
+		},
+		{
+			"duplicate declarations",
+			"main:(a:int): {\n    a :: 1\n}\n",
+			nil,
+			"a redeclared in this scope, previous declaration at line 1",
+		},
+		{
+			"unknown types",
+			"a :: 1\nb:a\n",
+			nil,
+			"unknown type a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewClient().Resolve(buildSrc(t, tt.src))
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if berr, ok := err.(*bear.Error); !ok || !berr.HasLabel(tt.wantErr) {
+					t.Errorf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			var got []string
+			for _, w := range res.Warnings {
+				got = append(got, strings.TrimPrefix(w.String(), filepath.Dir(w.Token.FileName)+"/"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Resolve() warnings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Check_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			"every statement is checked",
+			"main:(): {\n    a:string: 1\n    b :: a + 1\n    c:int: \"hi\"\n    if 1 {\n    }\n}\n",
+			[]string{
+				"cannot use int value as string",
+				"the + operator is not defined for string and int values",
+				"cannot use string value as int",
+				"expected a bool condition, got int",
+			},
+		},
+		{
+			"every declaration is checked",
+			"a:int: \"hi\"\nb:v2\nmain:(): {\n    c :: a\n}\n",
+			[]string{
+				"cannot use string value as int",
+				"unknown type v2",
+			},
+		},
+		{
+			"names with invalid types are not reported again",
+			"main:(): {\n    a:v2\n    b :: a.x\n}\n",
+			[]string{"unknown type v2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := errors.Errors(NewClient().Check(buildSrc(t, tt.src)))
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Check() errors = %v, want %v", errs, tt.want)
+			}
+
+			for i, err := range errs {
+				if berr, ok := err.(*bear.Error); !ok || !berr.HasLabel(tt.want[i]) {
+					t.Errorf("Check() error %d = %v, want %v", i, err, tt.want[i])
+				}
+			}
+		})
+	}
+}
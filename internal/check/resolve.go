@@ -0,0 +1,514 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lang"
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+// SymbolKind is the kind of declaration that introduced a symbol
+type SymbolKind int
+
+const (
+	VarSymbol SymbolKind = iota
+	FuncSymbol
+	ParamSymbol
+	InputSymbol
+	ImportSymbol
+	TypeSymbol
+	RecvSymbol
+	ModuleSymbol
+)
+
+// Symbol is a declared name along with every identifier that references it
+type Symbol struct {
+	Name  string
+	Kind  SymbolKind
+	Token lex.Token
+	Scope *Scope
+	Uses  []*lang.Ident
+
+	// Shadows is the declaration from an outer scope that the symbol hides, it is nil if nothing is hidden
+	Shadows *Symbol
+
+	// Captured is true if a function literal uses the symbol and it is declared inside of an enclosing function
+	Captured bool
+
+	// reads counts the uses that read the value, assigning a new value is not a read
+	reads int
+}
+
+// ScopeKind is the kind of node that opened a scope
+type ScopeKind int
+
+const (
+	FileScope ScopeKind = iota
+	FuncScope
+	BlockScope
+	StructScope
+)
+
+// Scope is a set of symbols declared in the same file, function, block or struct body
+type Scope struct {
+	Kind    ScopeKind
+	Parent  *Scope
+	Symbols map[string]*Symbol
+}
+
+// lookup finds the symbol for a name in the scope or any of its parents
+func (s *Scope) lookup(name string) (*Symbol, bool) {
+	for ; s != nil; s = s.Parent {
+		if sym, ok := s.Symbols[name]; ok {
+			return sym, true
+		}
+	}
+
+	return nil, false
+}
+
+// Resolution links the identifiers and declarations of a program to their symbols
+type Resolution struct {
+	File *Scope
+
+	// Uses maps every identifier that references a declared name to its symbol
+	Uses map[*lang.Ident]*Symbol
+
+	// Decls maps every declaring node to the symbol it declares
+	Decls map[lang.Node]*Symbol
+
+	// Types maps every struct type name to its symbol
+	Types map[*lang.TypeName]*Symbol
+
+	// Warnings are problems that do not stop the program from being built
+	Warnings []Warning
+}
+
+// Warning is a problem with the program that does not stop it from being built
+type Warning struct {
+	Code  string
+	Msg   string
+	Token lex.Token
+}
+
+// String returns the warning prefixed with its position in the source file
+func (w Warning) String() string {
+	return fmt.Sprintf("%s:%d:%d: warning: %s", w.Token.FileName, w.Token.LineNumber+1, w.Token.ColNumber+1, w.Msg)
+}
+
+// Diagnostic returns the warning as a diagnostic so it can be reported along with errors
+func (w Warning) Diagnostic() errors.Diagnostic {
+	return errors.Diagnostic{
+		Severity: errors.SeverityWarning,
+		Code:     w.Code,
+		Msg:      w.Msg,
+		File:     w.Token.FileName,
+		Line:     w.Token.LineNumber + 1,
+		Col:      w.Token.ColNumber + 1,
+		Token:    w.Token.Value,
+	}
+}
+
+// Resolve builds the scopes of a blowK program and links every identifier to the declaration it references
+// unused variables, unused imports and shadowed names are reported as warnings
+func (c *Client) Resolve(node lang.Node) (*Resolution, error) {
+	root, ok := node.(*lang.Root)
+	if !ok {
+		return nil, invalidNode(node)
+	}
+
+	r := &resolver{
+		res: &Resolution{
+			Uses:  make(map[*lang.Ident]*Symbol),
+			Decls: make(map[lang.Node]*Symbol),
+			Types: make(map[*lang.TypeName]*Symbol),
+		},
+		diags: errors.NewCollector(0),
+	}
+	r.push(FileScope)
+	r.res.File = r.scope
+
+	// every top level name can be used anywhere in the file so they are all declared first
+	for _, node := range root.Imports {
+		imp, ok := node.(*lang.Import)
+		if !ok {
+			return nil, invalidNode(node)
+		}
+		// declarations imported from other files are not known until the files are loaded so they can be used as any kind of name
+		kind := ImportSymbol
+		if imp.From != "" {
+			kind = ModuleSymbol
+		}
+		r.declare(imp, imp.LocalName(), kind, imp.Token)
+	}
+
+	for _, node := range root.Expressions {
+		v, ok := node.(*lang.Var)
+		if !ok {
+			r.diags.Add(checkError(node, "only imports and declarations are allowed at the top level"))
+			continue
+		}
+
+		kind := VarSymbol
+		switch {
+		case v.IsFuncDecl():
+			kind = FuncSymbol
+		case v.IsTypeDecl():
+			kind = TypeSymbol
+		}
+		r.declare(v, v.Name, kind, v.Token)
+	}
+
+	for _, node := range root.Expressions {
+		v, ok := node.(*lang.Var)
+		if !ok {
+			continue
+		}
+		if err := r.resolveDecl(v); err != nil {
+			return nil, err
+		}
+	}
+
+	if root.Main != nil {
+		if err := r.resolveValue(root.Main.Default); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sym := range r.symbols {
+		switch {
+		case sym.reads > 0:
+		case sym.Kind == ImportSymbol, sym.Kind == ModuleSymbol:
+			r.warn(sym.Token, errors.UnusedImportCode, "imported and not used: "+sym.Name)
+		case sym.Kind == VarSymbol && sym.Scope.Kind != FileScope:
+			r.warn(sym.Token, errors.UnusedVarCode, "declared and not used: "+sym.Name)
+		}
+	}
+
+	return r.res, r.diags.Err()
+}
+
+// resolver holds the state for a single call to Resolve
+type resolver struct {
+	res *Resolution
+
+	// scope is the inner most scope
+	scope *Scope
+
+	// symbols are all the declared symbols in the order they were declared
+	symbols []*Symbol
+
+	// diags collects every undefined and duplicate name so they can all be reported at once
+	diags *errors.Collector
+}
+
+// push starts a new inner scope
+func (r *resolver) push(kind ScopeKind) {
+	r.scope = &Scope{Kind: kind, Parent: r.scope, Symbols: make(map[string]*Symbol)}
+}
+
+// pop returns to the parent scope
+func (r *resolver) pop() {
+	r.scope = r.scope.Parent
+}
+
+// warn adds a warning at the token
+func (r *resolver) warn(tok lex.Token, code, msg string) {
+	r.res.Warnings = append(r.res.Warnings, Warning{Code: code, Msg: msg, Token: tok})
+}
+
+// declare adds a symbol for the declaring node to the current scope
+// names can only be declared once in each scope, declaring a name from an outer scope shadows it
+// a name that is declared twice is reported and every use keeps referencing the first declaration
+func (r *resolver) declare(node lang.Node, name string, kind SymbolKind, tok lex.Token) *Symbol {
+	sym := &Symbol{Name: name, Kind: kind, Token: tok, Scope: r.scope}
+	if node != nil {
+		r.res.Decls[node] = sym
+	}
+
+	if prev, ok := r.scope.Symbols[name]; ok {
+		r.diags.Add(resolveError(tok, errors.DuplicateName,
+			fmt.Sprintf("%s redeclared in this scope, previous declaration at line %d", name, prev.Token.LineNumber+1),
+			errors.WithCode(errors.DuplicateCode),
+			errors.WithHint("rename one of the declarations"),
+		))
+		return sym
+	}
+
+	if prev, ok := r.scope.Parent.lookup(name); ok && kind != RecvSymbol {
+		sym.Shadows = prev
+		r.warn(tok, errors.ShadowCode, fmt.Sprintf("%s shadows the declaration at line %d", name, prev.Token.LineNumber+1))
+	}
+
+	r.scope.Symbols[name] = sym
+	r.symbols = append(r.symbols, sym)
+
+	return sym
+}
+
+// use links the identifier to the symbol it references, undefined names are reported
+func (r *resolver) use(ident *lang.Ident, read bool) {
+	sym, ok := r.scope.lookup(ident.Name)
+	if !ok {
+		r.diags.Add(resolveError(ident.Token, errors.UndefinedName, "undefined: "+ident.Name,
+			errors.WithCode(errors.UndefinedCode),
+			undefinedHint(ident.Name),
+		))
+		return
+	}
+
+	if sym.Scope.Kind != FileScope {
+		for s := r.scope; s != sym.Scope; s = s.Parent {
+			sym.Captured = sym.Captured || s.Kind == FuncScope
+		}
+	}
+
+	sym.Uses = append(sym.Uses, ident)
+	if read {
+		sym.reads++
+	}
+	r.res.Uses[ident] = sym
+}
+
+// resolveDecl resolves the value of a top level declaration, the name has already been declared
+func (r *resolver) resolveDecl(v *lang.Var) error {
+	if err := r.resolveType(v.Type); err != nil {
+		return err
+	}
+
+	if st, ok := v.Default.(*lang.StructType); ok {
+		return r.resolveStruct(st, r.res.Decls[v])
+	}
+
+	return r.resolveValue(v.Default)
+}
+
+// resolveStruct resolves the fields of a struct type and the bodies of its methods
+// methods are resolved in a struct scope that declares the me receiver
+func (r *resolver) resolveStruct(st *lang.StructType, typ *Symbol) error {
+	for _, field := range st.Fields {
+		if err := r.resolveType(field.Type); err != nil {
+			return err
+		}
+		if err := r.resolveValue(field.Default); err != nil {
+			return err
+		}
+	}
+
+	if len(st.Methods) == 0 {
+		return nil
+	}
+
+	r.push(StructScope)
+	defer r.pop()
+
+	r.declare(nil, "me", RecvSymbol, typ.Token)
+	for _, method := range st.Methods {
+		if err := r.resolveValue(method.Default); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveType resolves the struct type names used in a type
+func (r *resolver) resolveType(typ lang.Node) error {
+	switch v := typ.(type) {
+	case nil:
+		return nil
+	case *lang.TypeName:
+		if builtins[v.Name] {
+			return nil
+		}
+		sym, ok := r.scope.lookup(v.Name)
+		if !ok || (sym.Kind != TypeSymbol && sym.Kind != ModuleSymbol) {
+			r.diags.Add(resolveError(v.Token, errors.UndefinedName, "unknown type "+v.Name,
+				errors.WithCode(errors.UnknownTypeCode),
+				unknownTypeHint(v.Name),
+			))
+			return nil
+		}
+		sym.reads++
+		r.res.Types[v] = sym
+		return nil
+	case *lang.ArrayType:
+		return r.resolveType(v.Elem)
+	case *lang.StructType:
+		for _, field := range v.Fields {
+			if err := r.resolveType(field.Type); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *lang.FuncType:
+		for _, param := range v.Params {
+			if err := r.resolveType(param.Type); err != nil {
+				return err
+			}
+		}
+		for _, ret := range v.Returns {
+			if err := r.resolveType(ret); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return invalidNode(typ)
+	}
+}
+
+// resolveStmt resolves a single statement, declarations are added to the current scope
+func (r *resolver) resolveStmt(node lang.Node) error {
+	switch v := node.(type) {
+	case nil, *lang.Comment:
+		return nil
+	case *lang.Var:
+		if err := r.resolveType(v.Type); err != nil {
+			return err
+		}
+
+		switch {
+		case v.IsFuncDecl():
+			// functions are declared before their body is resolved so they can call themselves
+			r.declare(v, v.Name, FuncSymbol, v.Token)
+			return r.resolveValue(v.Default)
+		case v.IsTypeDecl():
+			sym := r.declare(v, v.Name, TypeSymbol, v.Token)
+			return r.resolveStruct(v.Default.(*lang.StructType), sym)
+		}
+
+		// the value is resolved before the name is declared so it can not reference itself
+		if err := r.resolveValue(v.Default); err != nil {
+			return err
+		}
+		r.declare(v, v.Name, VarSymbol, v.Token)
+		return nil
+	case *lang.Destructure:
+		if err := r.resolveValue(v.Value); err != nil {
+			return err
+		}
+		for _, name := range v.Names {
+			r.declare(name, name.Name, VarSymbol, name.Token)
+		}
+		return nil
+	case *lang.Assign:
+		// assigning to a variable does not read it, assigning to a field or element does
+		if ident, ok := v.Target.(*lang.Ident); ok {
+			r.use(ident, false)
+		} else if err := r.resolveValue(v.Target); err != nil {
+			return err
+		}
+		return r.resolveValue(v.Value)
+	case *lang.Loop:
+		r.push(BlockScope)
+		defer r.pop()
+
+		if err := r.resolveStmt(v.Init); err != nil {
+			return err
+		}
+		if err := r.resolveValue(v.Cond); err != nil {
+			return err
+		}
+		if err := r.resolveStmt(v.Post); err != nil {
+			return err
+		}
+		return r.resolveBlock(v.Body)
+	case *lang.If:
+		if err := r.resolveValue(v.Cond); err != nil {
+			return err
+		}
+		if err := r.resolveBlock(v.Then); err != nil {
+			return err
+		}
+		switch e := v.Else.(type) {
+		case nil:
+			return nil
+		case *lang.Block:
+			return r.resolveBlock(e)
+		default:
+			return r.resolveStmt(e)
+		}
+	default:
+		return r.resolveValue(node)
+	}
+}
+
+// resolveBlock resolves each statement in the block inside of a new scope
+func (r *resolver) resolveBlock(block *lang.Block) error {
+	r.push(BlockScope)
+	defer r.pop()
+
+	return r.resolveStmts(block)
+}
+
+// resolveStmts resolves each statement in the block in the current scope
+func (r *resolver) resolveStmts(block *lang.Block) error {
+	for _, stmt := range block.Stmts {
+		if err := r.resolveStmt(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveValue resolves every identifier used in a value
+func (r *resolver) resolveValue(node lang.Node) error {
+	switch v := node.(type) {
+	case nil:
+		return nil
+	case *lang.Ident:
+		r.use(v, true)
+		return nil
+	case *lang.Field:
+		// field names belong to the struct type so only the struct value is resolved
+		return r.resolveValue(v.X)
+	case *lang.KeyValue:
+		return r.resolveValue(v.Value)
+	case *lang.Func:
+		return r.resolveFunc(v)
+	case *lang.StructType, *lang.TypeName, *lang.ArrayType, *lang.FuncType:
+		return r.resolveType(v)
+	}
+
+	for _, child := range node.Children() {
+		if err := r.resolveValue(child); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// resolveFunc resolves a function in a new function scope
+// the parameters and the top level statements of the body share the function scope
+func (r *resolver) resolveFunc(fn *lang.Func) error {
+	if err := r.resolveType(fn.Type); err != nil {
+		return err
+	}
+
+	r.push(FuncScope)
+	defer r.pop()
+
+	for _, param := range fn.Type.Params {
+		r.declare(param, param.Name, ParamSymbol, param.Token)
+	}
+	for _, input := range fn.Type.Inputs {
+		r.declare(input, input.Name, InputSymbol, input.Token)
+	}
+
+	return r.resolveStmts(fn.Body)
+}
+
+// resolveError returns a resolution error positioned at the token, opts set the code and hint
+func resolveError(tok lex.Token, errType bear.ErrType, msg string, opts ...bear.ErrOption) error {
+	return errors.New(append([]bear.ErrOption{
+		bear.WithErrType(errType),
+		bear.WithExitCode(errors.CheckFailed),
+		bear.WithLabels(msg),
+		errors.At(tok.FileName, tok.LineNumber, tok.ColNumber, tok.Value),
+	}, opts...)...)
+}
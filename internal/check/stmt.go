@@ -0,0 +1,437 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// checkStmt checks a single statement
+func (c *checker) checkStmt(node lang.Node) error {
+	switch v := node.(type) {
+	case nil, *lang.Comment:
+		return nil
+	case *lang.Var:
+		switch {
+		case v.IsFuncDecl():
+			fn := v.Default.(*lang.Func)
+			// functions are declared before their body is checked so they can call themselves
+			c.scope.Declare(v.Name, fn.Type)
+			return c.checkFunc(fn, nil)
+		case v.IsTypeDecl():
+			st := v.Default.(*lang.StructType)
+			c.scope.DeclareType(v.Name, st)
+			if err := c.checkStructType(st); err != nil {
+				return err
+			}
+			return c.checkMethods(v.Name, st)
+		}
+		return c.checkVar(v)
+	case *lang.Destructure:
+		return c.checkDestructure(v)
+	case *lang.Assign:
+		return c.checkAssign(v)
+	case *lang.Exec:
+		return c.checkExec(v)
+	case *lang.Pipeline:
+		return c.checkPipeline(v)
+	case *lang.Call:
+		_, err := c.checkCall(v)
+		return err
+	case *lang.IncDec:
+		switch v.X.(type) {
+		case *lang.Ident, *lang.Field, *lang.Index:
+		default:
+			return checkError(v, "the "+v.Op+" operator can only be used on variables")
+		}
+		typ, err := c.typeOf(v.X)
+		if err != nil {
+			return err
+		}
+		if !lang.IsInt(typ) {
+			return checkError(v, "the "+v.Op+" operator can only be used on ints")
+		}
+		return nil
+	case *lang.Append:
+		return c.checkAppend(v)
+	case *lang.Pop:
+		_, err := c.typeOf(v)
+		return err
+	case *lang.Loop:
+		c.pushScope()
+		defer c.popScope()
+
+		if err := c.checkStmt(v.Init); err != nil {
+			return err
+		}
+		if v.Cond != nil {
+			if err := c.checkCond(v.Cond); err != nil {
+				return err
+			}
+		}
+		if err := c.checkStmt(v.Post); err != nil {
+			return err
+		}
+		return c.checkBlock(v.Body)
+	case *lang.If:
+		if err := c.checkCond(v.Cond); err != nil {
+			return err
+		}
+		if err := c.checkBlock(v.Then); err != nil {
+			return err
+		}
+		switch e := v.Else.(type) {
+		case nil:
+			return nil
+		case *lang.Block:
+			return c.checkBlock(e)
+		default:
+			return c.checkStmt(e)
+		}
+	case *lang.Return:
+		return c.checkReturn(v)
+	default:
+		return checkError(node, "expected a statement")
+	}
+}
+
+// checkBlock checks each statement in the block inside of a new scope
+// an error in one statement is reported and the rest of the block is still checked
+func (c *checker) checkBlock(block *lang.Block) error {
+	c.pushScope()
+	defer c.popScope()
+
+	for _, stmt := range block.Stmts {
+		c.report(c.checkStmt(stmt))
+	}
+
+	return nil
+}
+
+// checkVar checks a variable declaration and declares the variable
+// variables declared with :: take the type of their default value
+func (c *checker) checkVar(v *lang.Var) error {
+	if v.Name == "me" {
+		return checkError(v, "me is reserved for the receiver of a method")
+	}
+	if v.Name == "len" {
+		return checkError(v, "len is reserved for the length of an array")
+	}
+
+	typ := v.Type
+	var err error
+	switch {
+	case typ != nil:
+		if err = c.validType(typ); err != nil {
+			typ = nil
+		} else if v.Default != nil {
+			err = c.assign(typ, v.Default)
+		}
+	default:
+		typ, err = c.typeOf(v.Default)
+	}
+
+	// declare the variable after the value is checked so it can not reference itself
+	// the variable is declared even if the value is invalid so its uses are not reported as undefined
+	c.scope.Declare(v.Name, typ)
+	return err
+}
+
+// checkDestructure checks a declaration that unpacks the return values of a function
+func (c *checker) checkDestructure(d *lang.Destructure) error {
+	call, ok := d.Value.(*lang.Call)
+	if !ok {
+		return checkError(d.Value, "only function calls can be destructured")
+	}
+
+	sig, err := c.checkCall(call)
+	if err == nil && len(sig.Returns) != len(d.Names) {
+		err = checkError(d, fmt.Sprintf("cannot assign %d return values to %d variables", len(sig.Returns), len(d.Names)))
+	}
+	if err != nil {
+		for _, ident := range d.Names {
+			c.scope.Declare(ident.Name, nil)
+		}
+		return err
+	}
+
+	for i, ident := range d.Names {
+		c.scope.Declare(ident.Name, sig.Returns[i])
+	}
+
+	return nil
+}
+
+// checkAssign checks an assignment to an existing variable, struct field or array element
+func (c *checker) checkAssign(a *lang.Assign) error {
+	switch v := a.Target.(type) {
+	case *lang.Ident, *lang.Field:
+	case *lang.Index:
+		if _, ok := c.cmdLit(v); ok {
+			return checkError(v, "cannot assign to a cmd literal")
+		}
+	default:
+		return checkError(a.Target, "cannot assign to this expression")
+	}
+
+	typ, err := c.typeOf(a.Target)
+	if err != nil {
+		return err
+	}
+
+	return c.assign(typ, a.Value)
+}
+
+// checkAppend checks an append of a single value or of every element of an array
+func (c *checker) checkAppend(a *lang.Append) error {
+	xType, err := c.typeOf(a.X)
+	if err != nil {
+		return err
+	}
+
+	var elem lang.Node
+	switch {
+	case lang.IsCmd(xType):
+		elem = &lang.TypeName{Name: "string"}
+	case lang.IsArray(xType):
+		elem = xType.(*lang.ArrayType).Elem
+	default:
+		return checkError(a, "values can not be appended to "+typeString(xType)+" values")
+	}
+
+	// a literal appended to a nested array is a single row unless it is a list of rows
+	if lit, ok := a.Value.(*lang.ArrayLit); ok {
+		if lang.IsArray(elem) && c.assign(elem, lit) == nil {
+			return nil
+		}
+		return c.assign(&lang.ArrayType{Elem: elem}, lit)
+	}
+
+	typ, err := c.typeOf(a.Value)
+	if err != nil {
+		return err
+	}
+	if !lang.SameType(elem, typ) && !lang.SameType(&lang.ArrayType{Elem: elem}, typ) {
+		return checkError(a.Value, fmt.Sprintf("cannot append %s value to %s", typeString(typ), typeString(xType)))
+	}
+
+	return nil
+}
+
+// checkReturn checks the returned values against the return types of the current function
+func (c *checker) checkReturn(ret *lang.Return) error {
+	if c.fn == nil {
+		return checkError(ret, "return outside of a function")
+	}
+
+	if len(ret.Values) != len(c.fn.Returns) {
+		return checkError(ret, fmt.Sprintf("expected %d return values but %d were given", len(c.fn.Returns), len(ret.Values)))
+	}
+
+	for i, value := range ret.Values {
+		if err := c.assign(c.fn.Returns[i], value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkFunc checks the body of a function, methods are given the struct type of their receiver
+func (c *checker) checkFunc(fn *lang.Func, recv lang.Node) error {
+	if err := c.validType(fn.Type); err != nil {
+		return err
+	}
+
+	parent := c.fn
+	c.fn = fn.Type
+	c.pushScope()
+	defer func() {
+		c.fn = parent
+		c.popScope()
+	}()
+
+	if recv != nil {
+		c.scope.Declare("me", recv)
+	}
+	for _, param := range fn.Type.Params {
+		c.scope.Declare(param.Name, param.Type)
+	}
+	for _, input := range fn.Type.Inputs {
+		c.scope.Declare(input.Name, &lang.TypeName{Name: "string"})
+	}
+
+	if err := c.checkBlock(fn.Body); err != nil {
+		return err
+	}
+	if len(fn.Type.Returns) > 0 && !terminates(fn.Body) {
+		return missingReturn(fn.Body)
+	}
+
+	return nil
+}
+
+// terminates returns true if control never reaches the end of the statement
+// loops without a condition only end by returning since blowK has no break statement
+func terminates(node lang.Node) bool {
+	switch v := node.(type) {
+	case *lang.Return:
+		return true
+	case *lang.Block:
+		return len(v.Stmts) > 0 && terminates(v.Stmts[len(v.Stmts)-1])
+	case *lang.If:
+		return v.Else != nil && terminates(v.Then) && terminates(v.Else)
+	case *lang.Loop:
+		return v.Cond == nil
+	default:
+		return false
+	}
+}
+
+// checkStructType checks the field types and default values of a struct type
+func (c *checker) checkStructType(st *lang.StructType) error {
+	for _, field := range st.Fields {
+		if err := c.validType(field.Type); err != nil {
+			return err
+		}
+		if field.Default == nil {
+			continue
+		}
+		if err := c.assign(field.Type, field.Default); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkMethods checks the body of every method of a named struct type
+func (c *checker) checkMethods(name string, st *lang.StructType) error {
+	for _, method := range st.Methods {
+		fn, ok := method.Default.(*lang.Func)
+		if !ok {
+			return checkError(method, "methods must be functions")
+		}
+		if err := c.checkFunc(fn, &lang.TypeName{Name: name}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCall checks the arguments of a function call and returns the signature of the called function
+func (c *checker) checkCall(call *lang.Call) (*lang.FuncType, error) {
+	var sig *lang.FuncType
+	switch v := call.Fn.(type) {
+	case *lang.Field:
+		typ, err := c.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+
+		st, ok := c.scope.StructType(typ)
+		name, named := typ.(*lang.TypeName)
+		if !ok || !named {
+			return nil, checkError(v, "only named struct types have methods")
+		}
+
+		method, ok := st.Method(v.Name.Name)
+		if !ok {
+			return nil, checkError(v.Name, name.SourceName()+" has no method "+v.Name.Name)
+		}
+		sig = method.Type.(*lang.FuncType)
+	default:
+		typ, err := c.typeOf(call.Fn)
+		if err != nil {
+			return nil, err
+		}
+
+		fn, ok := typ.(*lang.FuncType)
+		if !ok {
+			return nil, checkError(call.Fn, typeString(typ)+" values can not be called")
+		}
+		sig = fn
+	}
+
+	if len(call.Args) != len(sig.Params) {
+		return nil, checkError(call, fmt.Sprintf("expected %d arguments but %d were given", len(sig.Params), len(call.Args)))
+	}
+
+	for i, arg := range call.Args {
+		if err := c.assign(sig.Params[i].Type, arg); err != nil {
+			return nil, err
+		}
+	}
+
+	return sig, nil
+}
+
+// cmdLit returns the cmd literal for an index of an imported command
+func (c *checker) cmdLit(idx *lang.Index) (*lang.Cmd, bool) {
+	return c.scope.CmdLit(idx, func(name string) bool { return c.imports[name] })
+}
+
+// checkCmdLit checks that a cmd literal uses an imported command and that every argument can be passed to it
+func (c *checker) checkCmdLit(cmd *lang.Cmd) error {
+	if !c.imports[cmd.Name.Name] {
+		return notImported(cmd.Name)
+	}
+
+	return c.checkArgs(cmd.Args)
+}
+
+// checkExec checks a command that is run, the command can be imported or stored in a cmd variable
+func (c *checker) checkExec(e *lang.Exec) error {
+	cmd, ok := e.Expr.(*lang.Cmd)
+	if !ok {
+		return checkError(e.Expr, "only commands can be run")
+	}
+
+	typ, ok := c.scope.Lookup(cmd.Name.Name)
+	switch {
+	case ok && typ == nil:
+		return errSkip
+	case ok && !lang.IsCmd(typ):
+		return checkError(cmd.Name, cmd.Name.SourceName()+" is not a cmd")
+	case !ok && !c.imports[cmd.Name.Name]:
+		return notImported(cmd.Name)
+	}
+
+	return c.checkArgs(cmd.Args)
+}
+
+// checkArgs checks the arguments of a command, arrays and cmds are passed as one argument per element
+func (c *checker) checkArgs(args []lang.Node) error {
+	for _, arg := range args {
+		typ, err := c.typeOf(arg)
+		if err != nil {
+			return err
+		}
+		if _, ok := c.scope.StructType(typ); ok || lang.IsFn(typ) {
+			return checkError(arg, typeString(typ)+" values can not be passed to a command")
+		}
+	}
+
+	return nil
+}
+
+// checkPipeline checks that every stage of a pipeline is a command or a function call
+func (c *checker) checkPipeline(pipe *lang.Pipeline) error {
+	for _, stage := range pipe.Stages {
+		switch v := stage.(type) {
+		case *lang.Exec:
+			if err := c.checkExec(v); err != nil {
+				return err
+			}
+		case *lang.Call:
+			if _, err := c.checkCall(v); err != nil {
+				return err
+			}
+		default:
+			return checkError(stage, "only commands and function calls can be piped")
+		}
+	}
+
+	return nil
+}
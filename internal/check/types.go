@@ -0,0 +1,376 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// builtins are the type names that are always declared
+var builtins = map[string]bool{
+	"string": true,
+	"int":    true,
+	"bool":   true,
+	"cmd":    true,
+}
+
+// validType checks that every type name used in the type is a builtin or a declared struct type
+func (c *checker) validType(typ lang.Node) error {
+	switch v := typ.(type) {
+	case *lang.TypeName:
+		if builtins[v.Name] {
+			return nil
+		}
+		if _, ok := c.scope.LookupType(v.Name); ok {
+			return nil
+		}
+		return checkError(v, "unknown type "+v.SourceName(),
+			errors.WithCode(errors.UnknownTypeCode),
+			unknownTypeHint(v.SourceName()),
+		)
+	case *lang.ArrayType:
+		// arrays are stored as bash arrays so only values that fit in a single word can be elements
+		elem := v.Elem
+		for lang.IsArray(elem) {
+			elem = elem.(*lang.ArrayType).Elem
+		}
+		if !lang.IsString(elem) && !lang.IsInt(elem) && !lang.IsBool(elem) {
+			if err := c.validType(elem); err != nil {
+				return err
+			}
+			return checkError(v, "arrays of "+typeString(elem)+" are not supported",
+				errors.WithCode(errors.TypeCode),
+				errors.WithHint("array elements must be strings, ints or bools"),
+			)
+		}
+		return nil
+	case *lang.StructType:
+		for _, field := range v.Fields {
+			if err := c.validType(field.Type); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *lang.FuncType:
+		for _, param := range v.Params {
+			if err := c.validType(param.Type); err != nil {
+				return err
+			}
+		}
+		for _, ret := range v.Returns {
+			if err := c.validType(ret); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return invalidNode(typ)
+	}
+}
+
+// typeOf checks a value node and returns its type
+func (c *checker) typeOf(node lang.Node) (lang.Node, error) {
+	switch v := node.(type) {
+	case *lang.String:
+		return &lang.TypeName{Name: "string"}, nil
+	case *lang.Int:
+		return &lang.TypeName{Name: "int"}, nil
+	case *lang.Bool:
+		return &lang.TypeName{Name: "bool"}, nil
+	case *lang.Exec:
+		if err := c.checkExec(v); err != nil {
+			return nil, err
+		}
+		return &lang.TypeName{Name: "string"}, nil
+	case *lang.Pipeline:
+		if err := c.checkPipeline(v); err != nil {
+			return nil, err
+		}
+		return &lang.TypeName{Name: "string"}, nil
+	case *lang.Unary:
+		if err := c.checkCond(v.X); err != nil {
+			return nil, err
+		}
+		return &lang.TypeName{Name: "bool"}, nil
+	case *lang.Binary:
+		return c.typeOfBinary(v)
+	case *lang.Ident:
+		typ, ok := c.scope.Lookup(v.Name)
+		switch {
+		case !ok:
+			return nil, undefined(v)
+		case typ == nil:
+			return nil, errSkip
+		}
+		return typ, nil
+	case *lang.Func:
+		if err := c.checkFunc(v, nil); err != nil {
+			return nil, err
+		}
+		return v.Type, nil
+	case *lang.Cmd:
+		if err := c.checkCmdLit(v); err != nil {
+			return nil, err
+		}
+		return &lang.TypeName{Name: "cmd"}, nil
+	case *lang.Call:
+		sig, err := c.checkCall(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(sig.Returns) == 0 {
+			return nil, checkError(v, "function call has no value")
+		}
+		return sig.Returns[0], nil
+	case *lang.Field:
+		typ, err := c.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		st, ok := c.scope.StructType(typ)
+		if !ok {
+			return nil, checkError(v, typeString(typ)+" values have no fields")
+		}
+		field, ok := st.Field(v.Name.Name)
+		if !ok {
+			return nil, checkError(v.Name, "unknown field "+v.Name.Name)
+		}
+		return field.Type, nil
+	case *lang.StructLit:
+		return nil, checkError(v, "struct literals must be declared with a type")
+	case *lang.ArrayLit:
+		if len(v.Elems) == 0 {
+			return nil, checkError(v, "empty array literals must be declared with a type")
+		}
+		elem, err := c.typeOf(v.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range v.Elems[1:] {
+			if err := c.assign(elem, e); err != nil {
+				return nil, err
+			}
+		}
+		return &lang.ArrayType{Elem: elem}, nil
+	case *lang.Index:
+		if cmd, ok := c.cmdLit(v); ok {
+			if err := c.checkCmdLit(cmd); err != nil {
+				return nil, err
+			}
+			return &lang.TypeName{Name: "cmd"}, nil
+		}
+		x, err := c.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.checkInt(v.Index); err != nil {
+			return nil, err
+		}
+		if lang.IsCmd(x) {
+			return &lang.TypeName{Name: "string"}, nil
+		}
+		arr, ok := x.(*lang.ArrayType)
+		if !ok {
+			return nil, checkError(v, typeString(x)+" values can not be indexed")
+		}
+		return arr.Elem, nil
+	case *lang.Slice:
+		x, err := c.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		if !lang.IsArray(x) && !lang.IsCmd(x) {
+			return nil, checkError(v, typeString(x)+" values can not be sliced")
+		}
+		for _, bound := range []lang.Node{v.Low, v.High} {
+			if bound == nil {
+				continue
+			}
+			if err := c.checkInt(bound); err != nil {
+				return nil, err
+			}
+		}
+		return x, nil
+	case *lang.Len:
+		x, err := c.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		if !lang.IsArray(x) {
+			return nil, checkError(v, typeString(x)+" values have no length")
+		}
+		return &lang.TypeName{Name: "int"}, nil
+	case *lang.Pop:
+		x, err := c.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		if lang.IsCmd(x) {
+			return &lang.TypeName{Name: "string"}, nil
+		}
+		arr, ok := x.(*lang.ArrayType)
+		if !ok {
+			return nil, checkError(v, typeString(x)+" values can not be popped")
+		}
+		return arr.Elem, nil
+	default:
+		return nil, checkError(node, "expected a value")
+	}
+}
+
+// typeOfBinary checks both operands of a binary expression and returns the type of the result
+func (c *checker) typeOfBinary(b *lang.Binary) (lang.Node, error) {
+	if lang.IsLogical(b.Op) {
+		if err := c.checkCond(b.X); err != nil {
+			return nil, err
+		}
+		if err := c.checkCond(b.Y); err != nil {
+			return nil, err
+		}
+		return &lang.TypeName{Name: "bool"}, nil
+	}
+
+	x, err := c.typeOf(b.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := c.typeOf(b.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	if lang.IsComparison(b.Op) {
+		switch {
+		case !lang.SameType(x, y):
+			return nil, checkError(b, fmt.Sprintf("cannot compare %s and %s values", typeString(x), typeString(y)))
+		case lang.IsInt(x):
+		case lang.IsString(x) && b.Op != "<=" && b.Op != ">=":
+		case lang.IsBool(x) && (b.Op == "==" || b.Op == "!="):
+		default:
+			return nil, checkError(b, fmt.Sprintf("the %s operator can not compare %s values", b.Op, typeString(x)))
+		}
+		return &lang.TypeName{Name: "bool"}, nil
+	}
+
+	if lang.IsInt(x) && lang.IsInt(y) {
+		return x, nil
+	}
+	if lang.IsString(x) && lang.IsString(y) && b.Op == "+" {
+		return x, nil
+	}
+
+	return nil, checkError(b, fmt.Sprintf("the %s operator is not defined for %s and %s values", b.Op, typeString(x), typeString(y)))
+}
+
+// assign checks that the value can be stored in a variable of the wanted type
+// array and struct literals take their type from the variable so each element and field is checked on its own
+func (c *checker) assign(want lang.Node, value lang.Node) error {
+	switch v := value.(type) {
+	case *lang.ArrayLit:
+		arr, ok := want.(*lang.ArrayType)
+		if !ok {
+			return checkError(v, "cannot use array literal as "+typeString(want))
+		}
+		for _, elem := range v.Elems {
+			if err := c.assign(arr.Elem, elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *lang.StructLit:
+		st, ok := c.scope.StructType(want)
+		if !ok {
+			return checkError(v, "cannot use struct literal as "+typeString(want))
+		}
+		seen := make(map[string]bool)
+		for _, kv := range v.Fields {
+			field, ok := st.Field(kv.Key.Name)
+			if !ok {
+				return checkError(kv.Key, "unknown field "+kv.Key.Name)
+			}
+			if seen[kv.Key.Name] {
+				return checkError(kv.Key, "duplicate field "+kv.Key.Name)
+			}
+			seen[kv.Key.Name] = true
+			if err := c.assign(field.Type, kv.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	got, err := c.typeOf(value)
+	if err != nil {
+		return err
+	}
+	if !lang.SameType(want, got) {
+		return mismatch(value, got, want)
+	}
+
+	return nil
+}
+
+// checkInt checks that the value is an int
+func (c *checker) checkInt(node lang.Node) error {
+	typ, err := c.typeOf(node)
+	if err != nil {
+		return err
+	}
+	if !lang.IsInt(typ) {
+		return mismatch(node, typ, &lang.TypeName{Name: "int"})
+	}
+
+	return nil
+}
+
+// checkCond checks that the value is a bool so it can be used as a condition
+func (c *checker) checkCond(node lang.Node) error {
+	typ, err := c.typeOf(node)
+	if err != nil {
+		return err
+	}
+	if !lang.IsBool(typ) {
+		return checkError(node, "expected a bool condition, got "+typeString(typ))
+	}
+
+	return nil
+}
+
+// typeString returns the type the way it is written in blowK source
+func typeString(typ lang.Node) string {
+	switch v := typ.(type) {
+	case *lang.TypeName:
+		return v.SourceName()
+	case *lang.ArrayType:
+		return "[]" + typeString(v.Elem)
+	case *lang.StructType:
+		var fields []string
+		for _, field := range v.Fields {
+			fields = append(fields, field.Name+":"+typeString(field.Type))
+		}
+		return "<" + strings.Join(fields, "; ") + ">"
+	case *lang.FuncType:
+		var params, inputs, returns []string
+		for _, param := range v.Params {
+			params = append(params, typeString(param.Type))
+		}
+		for _, input := range v.Inputs {
+			inputs = append(inputs, input.Name)
+		}
+		for _, ret := range v.Returns {
+			returns = append(returns, typeString(ret))
+		}
+		s := "fn(" + strings.Join(params, ", ") + ")"
+		if len(inputs) > 0 {
+			s += "|" + strings.Join(inputs, ", ") + "|"
+		}
+		if len(returns) > 0 {
+			s += "<" + strings.Join(returns, ", ") + ">"
+		}
+		return s
+	default:
+		return fmt.Sprintf("%T", typ)
+	}
+}
@@ -0,0 +1,117 @@
+package errors
+
+import (
+	"sort"
+	"strings"
+)
+
+// List is every error found in the source code, sorted by their position
+type List []error
+
+// Error returns each error on its own line
+func (l List) Error() string {
+	var lines []string
+	for _, err := range l {
+		lines = append(lines, err.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Errors returns every error in a List, any other error is returned on its own
+func Errors(err error) []error {
+	switch v := err.(type) {
+	case nil:
+		return nil
+	case List:
+		return v
+	default:
+		return []error{err}
+	}
+}
+
+// Collector gathers the errors found in the source code so every error can be reported at once
+type Collector struct {
+	limit     int
+	errs      []error
+	truncated bool
+}
+
+// NewCollector creates a collector that keeps at most limit errors, a limit of 0 keeps every error
+func NewCollector(limit int) *Collector {
+	return &Collector{limit: limit}
+}
+
+// Add adds an error to the collector, a List is added one error at a time and nil errors are ignored
+// errors are dropped once the collector is full
+func (c *Collector) Add(err error) {
+	for _, err := range Errors(err) {
+		if c.Full() {
+			c.truncated = true
+			return
+		}
+		c.errs = append(c.errs, err)
+	}
+}
+
+// Len returns the number of errors that have been collected
+func (c *Collector) Len() int {
+	return len(c.errs)
+}
+
+// Full returns true if the collector has reached its limit
+func (c *Collector) Full() bool {
+	return c.limit > 0 && len(c.errs) >= c.limit
+}
+
+// Truncated returns true if any errors were dropped because the collector was full
+func (c *Collector) Truncated() bool {
+	return c.truncated
+}
+
+// Err returns nil if there are no errors and the error itself if there is only one
+// otherwise a List of every error is returned, sorted by their position in the source code
+func (c *Collector) Err() error {
+	switch len(c.errs) {
+	case 0:
+		return nil
+	case 1:
+		return c.errs[0]
+	}
+
+	// errors from the same file are sorted by line and column, files stay in the order they were first seen
+	// errors without a position are kept at the end
+	type pos struct {
+		file, line, col int
+		ok              bool
+	}
+	files := make(map[string]int)
+	positions := make(map[error]pos)
+	for _, err := range c.errs {
+		d, ok := Diagnose(err)
+		if !ok {
+			continue
+		}
+		if _, seen := files[d.File]; !seen {
+			files[d.File] = len(files)
+		}
+		positions[err] = pos{file: files[d.File], line: d.Line, col: d.Col, ok: true}
+	}
+
+	list := append(List{}, c.errs...)
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := positions[list[i]], positions[list[j]]
+		switch {
+		case a.ok != b.ok:
+			return a.ok
+		case a.file != b.file:
+			return a.file < b.file
+		case a.line != b.line:
+			return a.line < b.line
+		default:
+			return a.col < b.col
+		}
+	})
+
+	return list
+}
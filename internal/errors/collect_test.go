@@ -0,0 +1,92 @@
+package errors
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/bear"
+)
+
+func TestCollector_Err(t *testing.T) {
+	at := func(msg, file string, line, col int) error {
+		return New(bear.WithLabels(msg), At(file, line, col, "x"))
+	}
+
+	tests := []struct {
+		name          string
+		limit         int
+		errs          []error
+		want          []string
+		wantTruncated bool
+	}{
+		{
+			"no errors",
+			0,
+			[]error{nil, nil},
+			nil,
+			false,
+		},
+		{
+			"sorted by position",
+			0,
+			[]error{
+				at("c", "a.bk", 4, 0),
+				at("b", "a.bk", 2, 8),
+				New(bear.WithLabels("d")),
+				at("a", "a.bk", 2, 1),
+				at("e", "b.bk", 0, 0),
+			},
+			[]string{"a", "b", "c", "e", "d"},
+			false,
+		},
+		{
+			"lists are flattened",
+			0,
+			[]error{
+				List{at("b", "a.bk", 3, 0), at("a", "a.bk", 1, 0)},
+				at("c", "a.bk", 5, 0),
+			},
+			[]string{"a", "b", "c"},
+			false,
+		},
+		{
+			"limited",
+			2,
+			[]error{
+				at("a", "a.bk", 1, 0),
+				at("b", "a.bk", 2, 0),
+				at("c", "a.bk", 3, 0),
+			},
+			[]string{"a", "b"},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCollector(tt.limit)
+			for _, err := range tt.errs {
+				c.Add(err)
+			}
+
+			var got []string
+			for _, err := range Errors(c.Err()) {
+				d, ok := Diagnose(err)
+				if !ok {
+					// errors without a position are only checked by their label
+					if err.(*bear.Error).HasLabel("d") {
+						got = append(got, "d")
+					}
+					continue
+				}
+				got = append(got, d.Msg)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Err() = %v, want %v", got, tt.want)
+			}
+			if c.Truncated() != tt.wantTruncated {
+				t.Errorf("Truncated() = %v, want %v", c.Truncated(), tt.wantTruncated)
+			}
+		})
+	}
+}
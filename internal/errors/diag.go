@@ -0,0 +1,267 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bjatkin/bear"
+)
+
+// At positions an error at a token in a source file, line and col start at 0 like they do for tokens
+func At(file string, line, col int, token string) bear.ErrOption {
+	return func(e *bear.Error) {
+		e.Add(
+			bear.WithTag("file", file),
+			bear.WithTag("line", line+1),
+			bear.WithTag("col", col+1),
+			bear.WithTag("token", token),
+		)
+	}
+}
+
+// WithCode sets the diagnostic code of an error
+func WithCode(code string) bear.ErrOption {
+	return bear.WithTag("code", code)
+}
+
+// WithHint adds a hint about how to fix the problem to an error
+func WithHint(hint string) bear.ErrOption {
+	return bear.WithTag("hint", hint)
+}
+
+// Diagnostic Severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a problem in blowK source code along with where in the source it was found
+type Diagnostic struct {
+	Severity string
+	Code     string
+	Msg      string
+	Hint     string
+	File     string
+	Line     int
+	Col      int
+	Token    string
+}
+
+// End returns the line and column just past the end of the token the diagnostic points at
+func (d Diagnostic) End() (int, int) {
+	return d.Line, d.Col + tokenWidth(d.Token)
+}
+
+// jsonError is the part of a bear error that diagnostics are built from
+type jsonError struct {
+	ErrType  string                 `json:"errType"`
+	Msg      string                 `json:"msg"`
+	ExitCode int                    `json:"exitCode"`
+	Tags     map[string]interface{} `json:"tags"`
+	Labels   []string               `json:"labels"`
+	Parents  []jsonError            `json:"parents"`
+}
+
+// decode returns the public fields of a bear error
+// bear errors do not expose their fields so they are read back out of the json error
+func decode(err error) (jsonError, bool) {
+	berr, ok := err.(*bear.Error)
+	if !ok {
+		return jsonError{}, false
+	}
+
+	var public jsonError
+	if err := json.Unmarshal([]byte(berr.Error()), &public); err != nil {
+		return jsonError{}, false
+	}
+
+	return public, true
+}
+
+// Diagnose returns the diagnostic for an error
+// the error or one of its parents must be positioned with At, otherwise false is returned
+func Diagnose(err error) (Diagnostic, bool) {
+	public, ok := decode(err)
+	if !ok {
+		return Diagnostic{}, false
+	}
+
+	return diagnose(public)
+}
+
+// ExitCode returns the exit code attached to the error with bear.WithExitCode
+// the first error in a List is used and errors without an exit code exit with 1
+func ExitCode(err error) int {
+	if errs := Errors(err); len(errs) > 0 {
+		err = errs[0]
+	}
+
+	public, ok := decode(err)
+	if !ok || public.ExitCode == 0 {
+		return 1
+	}
+
+	return public.ExitCode
+}
+
+// Message returns a single line description of the error made from its type, labels and the messages of its parents
+//
+//	File Not Found: open src.bk: no such file or directory
+func Message(err error) string {
+	public, ok := decode(err)
+	if !ok {
+		return err.Error()
+	}
+
+	var parts []string
+	if public.ErrType != "" {
+		parts = append(parts, public.ErrType)
+	}
+
+	return strings.Join(append(parts, message(public)...), ": ")
+}
+
+// message returns the labels or message of an error followed by the messages of its parents
+func message(e jsonError) []string {
+	var parts []string
+	switch {
+	case len(e.Labels) > 0:
+		parts = append(parts, strings.Join(e.Labels, ", "))
+	case e.Msg != "":
+		parts = append(parts, e.Msg)
+	}
+
+	for _, parent := range e.Parents {
+		parts = append(parts, message(parent)...)
+	}
+
+	return parts
+}
+
+// diagnose returns the diagnostic for the first error in the tree that has a position
+func diagnose(e jsonError) (Diagnostic, bool) {
+	file, ok := e.Tags["file"].(string)
+	if !ok {
+		for _, parent := range e.Parents {
+			if d, ok := diagnose(parent); ok {
+				return d, true
+			}
+		}
+		return Diagnostic{}, false
+	}
+
+	d := Diagnostic{
+		Severity: SeverityError,
+		Code:     SyntaxCode,
+		Msg:      strings.Join(e.Labels, ", "),
+		File:     file,
+	}
+	if code, ok := e.Tags["code"].(string); ok {
+		d.Code = code
+	}
+	if hint, ok := e.Tags["hint"].(string); ok {
+		d.Hint = hint
+	}
+	if token, ok := e.Tags["token"].(string); ok {
+		d.Token = token
+	}
+	if line, ok := e.Tags["line"].(float64); ok {
+		d.Line = int(line)
+	}
+	if col, ok := e.Tags["col"].(float64); ok {
+		d.Col = int(col)
+	}
+
+	return d, true
+}
+
+// Render writes the diagnostic followed by the line of source code it points at
+// the token is underlined with a caret at its first character
+//
+//	src.bk:2:10: error[BK0003]: undefined: b
+//	  2 |     a :: b
+//	    |          ^
+//	    = hint: check the spelling of b or declare it before it is used
+func (d Diagnostic) Render(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%s:%d:%d: %s[%s]: %s\n", d.File, d.Line, d.Col, d.Severity, d.Code, d.Msg); err != nil {
+		return err
+	}
+
+	gutter := strings.Repeat(" ", len(strconv.Itoa(d.Line))+3)
+	if src, ok := sourceLine(d.File, d.Line); ok {
+		if _, err := fmt.Fprintf(w, "  %d | %s\n%s| %s\n", d.Line, src, gutter, underline(src, d.Col, d.Token)); err != nil {
+			return err
+		}
+	}
+
+	if d.Hint != "" {
+		if _, err := fmt.Fprintf(w, "%s= hint: %s\n", gutter, d.Hint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sourceLine reads a single line from the source file, line starts at 1
+func sourceLine(file string, line int) (string, bool) {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+
+	lines := strings.Split(string(src), "\n")
+	if line < 1 || line > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[line-1], "\r"), true
+}
+
+// underline returns the caret line for the token at col in the source line, col starts at 1
+// tabs before the token are kept so the caret lines up with the source no matter how wide a tab is shown
+func underline(src string, col int, token string) string {
+	runes := []rune(src)
+	if col < 1 {
+		col = 1
+	}
+	if col > len(runes)+1 {
+		col = len(runes) + 1
+	}
+
+	var b strings.Builder
+	for _, r := range runes[:col-1] {
+		if r == '\t' {
+			b.WriteRune('\t')
+			continue
+		}
+		b.WriteRune(' ')
+	}
+
+	// the underline stops at the end of the line for tokens that are longer than the rest of the line
+	width := tokenWidth(token)
+	if rest := len(runes) - col + 1; width > rest && rest > 0 {
+		width = rest
+	}
+	b.WriteString("^")
+	if width > 1 {
+		b.WriteString(strings.Repeat("~", width-1))
+	}
+
+	return b.String()
+}
+
+// tokenWidth returns the number of characters a token covers, tokens that are only whitespace still cover one
+func tokenWidth(token string) int {
+	width := utf8.RuneCountInString(strings.TrimSpace(token))
+	if width < 1 {
+		return 1
+	}
+
+	return width
+}
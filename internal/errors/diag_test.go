@@ -0,0 +1,133 @@
+package errors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/bear"
+)
+
+func TestDiagnostic_Render(t *testing.T) {
+	srcFile := filepath.Join(t.TempDir(), "src.bk")
+	src := "main:(): {\n    a :: bogus + 1\n\tb :: 1 +\n}\n"
+	if err := os.WriteFile(srcFile, []byte(src), 0644); err != nil {
+		t.Fatalf("Render() failed to write src file %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"underlined token with a hint",
+			New(
+				bear.WithLabels("undefined: bogus"),
+				WithCode(UndefinedCode),
+				WithHint("declare bogus before it is used"),
+				At(srcFile, 1, 9, "bogus"),
+			),
+			"src.bk:2:10: error[BK0003]: undefined: bogus\n" +
+				"  2 |     a :: bogus + 1\n" +
+				"    |          ^~~~~\n" +
+				"    = hint: declare bogus before it is used\n",
+		},
+		{
+			"tabs and the end of the line",
+			Wrap(New(
+				bear.WithLabels("expected an expression"),
+				At(srcFile, 2, 9, "\n"),
+			)),
+			"src.bk:3:10: error[BK0001]: expected an expression\n" +
+				"  3 | \tb :: 1 +\n" +
+				"    | \t        ^\n",
+		},
+		{
+			"missing source file",
+			New(
+				bear.WithLabels("unknown type v2"),
+				WithCode(UnknownTypeCode),
+				At(filepath.Join(filepath.Dir(srcFile), "missing.bk"), 0, 0, "v2"),
+			),
+			"missing.bk:1:1: error[BK0005]: unknown type v2\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := Diagnose(tt.err)
+			if !ok {
+				t.Fatalf("Diagnose() = %v, want a diagnostic", ok)
+			}
+
+			var got strings.Builder
+			if err := d.Render(&got); err != nil {
+				t.Fatalf("Render() unexpected error %v", err)
+			}
+			if trimmed := strings.TrimPrefix(got.String(), filepath.Dir(srcFile)+"/"); trimmed != tt.want {
+				t.Errorf("Render() = %q, want %q", trimmed, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	if _, ok := Diagnose(New(bear.WithLabels("no position"))); ok {
+		t.Errorf("Diagnose() = %v, want no diagnostic for errors without a position", ok)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"attached exit code", New(bear.WithExitCode(LexerFailed)), LexerFailed},
+		{"first error in a list", List{New(bear.WithExitCode(ASTFailed)), New(bear.WithExitCode(CheckFailed))}, ASTFailed},
+		{"no exit code", New(bear.WithLabels("no exit code")), 1},
+		{"not a bear error", List{os.ErrNotExist}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			"wrapped error",
+			Wrap(os.ErrNotExist, bear.WithErrType(FileNotFound), bear.WithTag("src name", "src.bk")),
+			"File Not Found: file does not exist",
+		},
+		{
+			"labels",
+			New(bear.WithErrType(InvalidArgs), bear.WithLabels("only one source file can be written to stdout")),
+			"Invalid Arguments: only one source file can be written to stdout",
+		},
+		{
+			"not a bear error",
+			os.ErrNotExist,
+			"file does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Message(tt.err); got != tt.want {
+				t.Errorf("Message() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// Diagnostic Formats
+const (
+	TextFormat  = "text"
+	JSONFormat  = "json"
+	SARIFFormat = "sarif"
+)
+
+// Formats are the diagnostic formats that can be written, in the order they are shown in help text
+var Formats = []string{TextFormat, JSONFormat, SARIFFormat}
+
+// jsonPosition is a position in a source file, line and col start at 1
+type jsonPosition struct {
+	Line int `json:"line"`
+	Col  int `json:"col"`
+}
+
+// jsonRange is the range of source code a diagnostic covers, the end is just past the last character
+type jsonRange struct {
+	Start jsonPosition `json:"start"`
+	End   jsonPosition `json:"end"`
+}
+
+// jsonDiagnostic is a single diagnostic written by WriteJSON
+type jsonDiagnostic struct {
+	File     string    `json:"file"`
+	Range    jsonRange `json:"range"`
+	Severity string    `json:"severity"`
+	Code     string    `json:"code"`
+	Message  string    `json:"message"`
+	Hint     string    `json:"hint,omitempty"`
+}
+
+// WriteJSON writes the diagnostics as a single json document
+//
+//	{"diagnostics": [{"file": "src.bk", "range": {...}, "severity": "error", "code": "BK0003", "message": "undefined: b"}]}
+func WriteJSON(w io.Writer, diags []Diagnostic) error {
+	doc := struct {
+		Diagnostics []jsonDiagnostic `json:"diagnostics"`
+	}{
+		Diagnostics: []jsonDiagnostic{},
+	}
+
+	for _, d := range diags {
+		endLine, endCol := d.End()
+		doc.Diagnostics = append(doc.Diagnostics, jsonDiagnostic{
+			File: d.File,
+			Range: jsonRange{
+				Start: jsonPosition{Line: d.Line, Col: d.Col},
+				End:   jsonPosition{Line: endLine, Col: endCol},
+			},
+			Severity: d.Severity,
+			Code:     d.Code,
+			Message:  d.Msg,
+			Hint:     d.Hint,
+		})
+	}
+
+	return encode(w, doc)
+}
+
+// sarifVersion is the version of the SARIF spec that WriteSARIF follows
+const sarifVersion = "2.1.0"
+
+// sarifSchema is the json schema for sarifVersion
+const sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// sarifLog is the root object of a SARIF file
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifRun is a single run of a tool and the results it found
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+// sarifTool is the tool that produced a run
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+// sarifDriver describes the tool and every rule that it reported a result for
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+// sarifRule is a kind of problem the tool can report, blowk uses the diagnostic code as the rule id
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+// sarifResult is a single problem found by the tool
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifMessage      `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+// sarifMessage is the text shown for a result
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+// sarifLocation is where a result was found
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// sarifPhysicalLocation is a region of a file
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+// sarifArtifactLocation is the file a result was found in
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifRegion is a range of source code, lines and columns start at 1 and the end column is just past the last character
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine"`
+	EndColumn   int `json:"endColumn"`
+}
+
+// WriteSARIF writes the diagnostics as a SARIF log with a single run of the blowk tool
+// hints are written to the hint property of each result
+func WriteSARIF(w io.Writer, diags []Diagnostic) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "blowk",
+				InformationURI: "https://github.com/bjatkin/blow-k",
+				Rules:          []sarifRule{},
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]bool)
+	for _, d := range diags {
+		if !rules[d.Code] {
+			rules[d.Code] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: d.Code})
+		}
+
+		var props map[string]string
+		if d.Hint != "" {
+			props = map[string]string{"hint": d.Hint}
+		}
+
+		endLine, endCol := d.End()
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  d.Code,
+			Level:   d.Severity,
+			Message: sarifMessage{Text: d.Msg},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(d.File)},
+					Region: sarifRegion{
+						StartLine:   d.Line,
+						StartColumn: d.Col,
+						EndLine:     endLine,
+						EndColumn:   endCol,
+					},
+				},
+			}},
+			Properties: props,
+		})
+	}
+	sort.Slice(run.Tool.Driver.Rules, func(i, j int) bool {
+		return run.Tool.Driver.Rules[i].ID < run.Tool.Driver.Rules[j].ID
+	})
+
+	return encode(w, sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []sarifRun{run},
+	})
+}
+
+// encode writes v as indented json
+func encode(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
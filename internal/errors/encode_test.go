@@ -0,0 +1,142 @@
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var encodeDiags = []Diagnostic{
+	{
+		Severity: SeverityError,
+		Code:     UndefinedCode,
+		Msg:      "undefined: bogus",
+		Hint:     "declare bogus before it is used",
+		File:     "src.bk",
+		Line:     2,
+		Col:      10,
+		Token:    "bogus",
+	},
+	{
+		Severity: SeverityWarning,
+		Code:     UnusedVarCode,
+		Msg:      "declared and not used: a",
+		File:     "src.bk",
+		Line:     3,
+		Col:      5,
+		Token:    "a",
+	},
+}
+
+func TestWriteJSON(t *testing.T) {
+	var b strings.Builder
+	if err := WriteJSON(&b, encodeDiags); err != nil {
+		t.Fatalf("WriteJSON() unexpected error %v", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid json %v", err)
+	}
+
+	want := map[string]interface{}{
+		"diagnostics": []interface{}{
+			map[string]interface{}{
+				"file": "src.bk",
+				"range": map[string]interface{}{
+					"start": map[string]interface{}{"line": 2.0, "col": 10.0},
+					"end":   map[string]interface{}{"line": 2.0, "col": 15.0},
+				},
+				"severity": "error",
+				"code":     "BK0003",
+				"message":  "undefined: bogus",
+				"hint":     "declare bogus before it is used",
+			},
+			map[string]interface{}{
+				"file": "src.bk",
+				"range": map[string]interface{}{
+					"start": map[string]interface{}{"line": 3.0, "col": 5.0},
+					"end":   map[string]interface{}{"line": 3.0, "col": 6.0},
+				},
+				"severity": "warning",
+				"code":     "BK0014",
+				"message":  "declared and not used: a",
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteJSON() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	tests := []struct {
+		name        string
+		diags       []Diagnostic
+		wantRules   []string
+		wantResults []sarifResult
+	}{
+		{
+			"no diagnostics",
+			nil,
+			[]string{},
+			[]sarifResult{},
+		},
+		{
+			"errors and warnings",
+			encodeDiags,
+			[]string{"BK0003", "BK0014"},
+			[]sarifResult{
+				{
+					RuleID:  "BK0003",
+					Level:   "error",
+					Message: sarifMessage{Text: "undefined: bogus"},
+					Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: "src.bk"},
+						Region:           sarifRegion{StartLine: 2, StartColumn: 10, EndLine: 2, EndColumn: 15},
+					}}},
+					Properties: map[string]string{"hint": "declare bogus before it is used"},
+				},
+				{
+					RuleID:  "BK0014",
+					Level:   "warning",
+					Message: sarifMessage{Text: "declared and not used: a"},
+					Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: "src.bk"},
+						Region:           sarifRegion{StartLine: 3, StartColumn: 5, EndLine: 3, EndColumn: 6},
+					}}},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := WriteSARIF(&b, tt.diags); err != nil {
+				t.Fatalf("WriteSARIF() unexpected error %v", err)
+			}
+
+			var got sarifLog
+			if err := json.Unmarshal([]byte(b.String()), &got); err != nil {
+				t.Fatalf("WriteSARIF() wrote invalid json %v", err)
+			}
+			if got.Version != sarifVersion || len(got.Runs) != 1 {
+				t.Fatalf("WriteSARIF() = %v, want a single %s run", got, sarifVersion)
+			}
+
+			run := got.Runs[0]
+			rules := []string{}
+			for _, rule := range run.Tool.Driver.Rules {
+				rules = append(rules, rule.ID)
+			}
+			if !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("WriteSARIF() rules = %v, want %v", rules, tt.wantRules)
+			}
+			if !reflect.DeepEqual(run.Results, tt.wantResults) {
+				t.Errorf("WriteSARIF() results = %v, want %v", run.Results, tt.wantResults)
+			}
+		})
+	}
+}
@@ -4,10 +4,15 @@ import "github.com/bjatkin/bear"
 
 // Error Types
 var (
-	FileNotFound      = bear.NewType("File Not Found")
-	InvalidJSON       = bear.NewType("Invalid JSON")
-	InvalidLexMatcher = bear.NewType("Invalid Lex Matcher")
-	SyntaxError       = bear.NewType("Syntax Error")
+	FileNotFound  = bear.NewType("File Not Found")
+	InvalidArgs   = bear.NewType("Invalid Arguments")
+	InvalidJSON   = bear.NewType("Invalid JSON")
+	SyntaxError   = bear.NewType("Syntax Error")
+	Unformatted   = bear.NewType("Unformatted Source")
+	InvalidNode   = bear.NewType("Invalid Node")
+	UndefinedName = bear.NewType("Undefined Name")
+	DuplicateName = bear.NewType("Duplicate Name")
+	ImportCycle   = bear.NewType("Import Cycle")
 )
 
 // Exit Codes
@@ -16,6 +21,33 @@ const (
 	TokenizerFailed
 	LexerFailed
 	ASTFailed
+	GenFailed
+	FmtFailed
+	CheckFailed
+	LoadFailed
+	RunFailed
+)
+
+// Diagnostic Codes
+// every kind of problem in blowK source code has a stable code so it can be looked up, codes must never be reused
+const (
+	SyntaxCode         = "BK0001"
+	InvalidImportCode  = "BK0002"
+	UndefinedCode      = "BK0003"
+	DuplicateCode      = "BK0004"
+	UnknownTypeCode    = "BK0005"
+	MismatchCode       = "BK0006"
+	TypeCode           = "BK0007"
+	NotImportedCode    = "BK0008"
+	ImportCycleCode    = "BK0009"
+	ImportNotFoundCode = "BK0010"
+	GenCode            = "BK0011"
+	InvalidTokenCode   = "BK0012"
+	UnclosedStringCode = "BK0013"
+	UnusedVarCode      = "BK0014"
+	UnusedImportCode   = "BK0015"
+	ShadowCode         = "BK0016"
+	MissingReturnCode  = "BK0017"
 )
 
 // base error template
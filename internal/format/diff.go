@@ -0,0 +1,160 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change in a diff
+const context = 3
+
+// edit is a single line in a diff
+type edit struct {
+	op   byte
+	line string
+}
+
+// Diff returns a unified diff between the before and after source
+// an empty string is returned if the sources are the same
+func Diff(name string, before, after []byte) string {
+	if string(before) == string(after) {
+		return ""
+	}
+
+	edits := diffLines(splitLines(string(before)), splitLines(string(after)))
+
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "--- %s\n+++ %s (formatted)\n", name, name)
+
+	// aLine and bLine track the line numbers of the current edit in each source
+	var aLine, bLine int
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+
+		// find the end of the hunk, changes closer than 2 * context lines are joined
+		end := i
+		for j := i; j < len(edits); j++ {
+			if edits[j].op != ' ' {
+				end = j + 1
+				continue
+			}
+			if j-end >= 2*context {
+				break
+			}
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		stop := end + context
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+		var aCount, bCount int
+		for _, e := range edits[start:stop] {
+			if e.op != '+' {
+				aCount++
+			}
+			if e.op != '-' {
+				bCount++
+			}
+		}
+
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, e := range edits[start:stop] {
+			fmt.Fprintf(buf, "%c%s", e.op, e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				fmt.Fprint(buf, "\n\\ No newline at end of file\n")
+			}
+		}
+
+		for _, e := range edits[i:stop] {
+			if e.op != '+' {
+				aLine++
+			}
+			if e.op != '-' {
+				bLine++
+			}
+		}
+		i = stop
+	}
+
+	return buf.String()
+}
+
+// hunkRange formats the line range of a hunk
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits the source into lines, each line keeps its newline
+// so a final line without a newline is different from the same line with one
+func splitLines(src string) []string {
+	if src == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(src, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines finds the shortest set of edits that convert a into b using the longest common subsequence
+func diffLines(a, b []string) []edit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var edits []edit
+	var i, j int
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{op: ' ', line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{op: '-', line: a[i]})
+			i++
+		default:
+			edits = append(edits, edit{op: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{op: '-', line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{op: '+', line: b[j]})
+	}
+
+	return edits
+}
@@ -0,0 +1,554 @@
+package format
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+// Client is a format client that converts a slice of lex.Tokens back into canonical blowK source
+type Client struct {
+	indent string
+}
+
+// NewClient creates a new default format.Client
+func NewClient() *Client {
+	return &Client{
+		indent: "    ",
+	}
+}
+
+// Format returns the canonical source code for the tokens
+// line breaks, blank lines and comments are kept from the original source
+func (c *Client) Format(tokens []lex.Token) []byte {
+	p := &printer{
+		client: c,
+		tokens: tokens,
+	}
+
+	return []byte(p.print())
+}
+
+// bracket is an open bracket waiting to be closed
+type bracket struct {
+	closer string
+	broken bool
+
+	// literal is true for braces that hold a struct literal rather than a block
+	literal bool
+}
+
+// printer holds the state for a single call to Format
+type printer struct {
+	client *Client
+	tokens []lex.Token
+	buf    strings.Builder
+	stack  []bracket
+	indent int
+}
+
+// print writes all the tokens into the buffer and returns the formatted source
+func (p *printer) print() string {
+	prev := -1
+	for i, tok := range p.tokens {
+		if p.hidden(i) {
+			continue
+		}
+
+		switch {
+		case prev < 0:
+			// nothing to separate the first token from
+		case tok.LineNumber > endLine(p.tokens[prev]):
+			p.newLine(tok, tok.LineNumber > endLine(p.tokens[prev])+1)
+		default:
+			p.closeBracket(tok)
+			p.buf.WriteString(p.space(prev, i))
+		}
+
+		p.buf.WriteString(text(tok))
+		p.openBracket(i)
+		prev = i
+	}
+
+	if p.buf.Len() == 0 {
+		return ""
+	}
+
+	return p.buf.String() + "\n"
+}
+
+// hidden returns true if the token at index i should not be printed
+// semicolons that end a line are implied by the new line
+func (p *printer) hidden(i int) bool {
+	tok := p.tokens[i]
+	if tok.T != lex.SemiColon {
+		return false
+	}
+
+	if i+1 >= len(p.tokens) {
+		return true
+	}
+
+	return p.tokens[i+1].LineNumber > tok.LineNumber
+}
+
+// newLine starts a new line for tok at the correct indent
+func (p *printer) newLine(tok lex.Token, blank bool) {
+	if !p.closeBracket(tok) && len(p.stack) > 0 {
+		top := &p.stack[len(p.stack)-1]
+		if !top.broken {
+			top.broken = true
+			p.indent++
+		}
+	}
+
+	p.buf.WriteString("\n")
+	if blank {
+		p.buf.WriteString("\n")
+	}
+	p.buf.WriteString(strings.Repeat(p.client.indent, p.indent))
+}
+
+// openBracket pushes the token at i onto the bracket stack if it opens a bracket
+func (p *printer) openBracket(i int) {
+	tok := p.tokens[i]
+	if closer, ok := brackets[tok.T]; ok {
+		p.stack = append(p.stack, bracket{closer: closer, literal: tok.T == lex.OpenBrace && !p.isBlock(i)})
+	}
+
+	// a < that ends a line opens a multi line struct type
+	if tok.T == lex.LessThan && p.endsLine(i) {
+		p.stack = append(p.stack, bracket{closer: ">"})
+	}
+}
+
+// isBlock returns true if the brace at i opens a function or statement body rather than a struct literal
+// a function body follows a function signature, optionally separated by a colon
+func (p *printer) isBlock(i int) bool {
+	if p.statementBody(i) {
+		return true
+	}
+
+	j := i - 1
+	if j >= 0 && p.tokens[j].T == lex.Colon {
+		j--
+	}
+	if j < 0 {
+		return false
+	}
+
+	switch p.tokens[j].T {
+	case lex.CloseParen, lex.Pipe, lex.Or:
+		return true
+	case lex.GreaterThan:
+		// a list of return types follows the closing paren of the parameters
+		depth := 0
+		for k := j; k >= 0; k-- {
+			switch p.tokens[k].T {
+			case lex.GreaterThan:
+				depth++
+			case lex.LessThan:
+				depth--
+			}
+			if depth == 0 {
+				return k > 0 && (p.tokens[k-1].T == lex.CloseParen || p.tokens[k-1].T == lex.Pipe || p.tokens[k-1].T == lex.Or)
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// statementBody returns true if the brace at i opens the body of an if, else or loop
+// a struct literal in the condition follows an operator so it can not be mistaken for the body
+func (p *printer) statementBody(i int) bool {
+	if i == 0 {
+		return false
+	}
+
+	switch before := p.tokens[i-1]; before.T {
+	case lex.ElseKeyword, lex.LoopKeyword:
+		return true
+	case lex.Increment, lex.Decrement:
+	default:
+		if !lex.IsOperand(before) {
+			return false
+		}
+	}
+
+	for j := i - 1; j >= 0; j-- {
+		switch p.tokens[j].T {
+		case lex.IfKeyword, lex.LoopKeyword:
+			return true
+		case lex.SemiColon, lex.OpenBrace, lex.CloseBrace:
+			return false
+		}
+	}
+
+	return false
+}
+
+// endsLine returns true if the token at i is the last printed token on its line
+func (p *printer) endsLine(i int) bool {
+	for j := i + 1; j < len(p.tokens); j++ {
+		if !p.hidden(j) {
+			return p.tokens[j].LineNumber > endLine(p.tokens[i])
+		}
+	}
+
+	return true
+}
+
+// closeBracket pops the bracket stack if tok closes the inner most bracket
+// it returns true if a bracket was closed
+func (p *printer) closeBracket(tok lex.Token) bool {
+	if tok.T == lex.String || tok.T == lex.Comment ||
+		len(p.stack) == 0 || p.stack[len(p.stack)-1].closer != tok.Value {
+		return false
+	}
+
+	top := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	if top.broken {
+		p.indent--
+	}
+
+	return true
+}
+
+// inner returns the closing value of the inner most open bracket
+func (p *printer) inner() string {
+	if len(p.stack) == 0 {
+		return ""
+	}
+
+	return p.stack[len(p.stack)-1].closer
+}
+
+// space returns the canonical spacing between the printed token at prev and the token at i
+func (p *printer) space(prev, i int) string {
+	before, tok := p.tokens[prev], p.tokens[i]
+
+	switch {
+	case tok.T == lex.Comma || tok.T == lex.SemiColon:
+		return ""
+	case before.T == lex.Comma || before.T == lex.SemiColon:
+		return " "
+	case tok.T == lex.CloseParen || tok.T == lex.CloseSquare:
+		return ""
+	case tok.T == lex.Dot || before.T == lex.Dot:
+		return ""
+	case tok.T == lex.Comment:
+		return " "
+	case before.T == lex.DoubleColon || tok.T == lex.DoubleColon:
+		return " "
+	case tok.T == lex.Colon:
+		if p.assignColon(prev) {
+			return " "
+		}
+		return ""
+	case before.T == lex.Colon:
+		if p.inner() == "]" || p.tightColon(prev) {
+			return ""
+		}
+		return " "
+	case tok.T == lex.OpenBrace:
+		if before.T == lex.OpenParen || before.T == lex.OpenSquare {
+			return ""
+		}
+		return " "
+	case before.T == lex.OpenBrace:
+		if tok.T == lex.CloseBrace {
+			return ""
+		}
+		return " "
+	case tok.T == lex.CloseBrace:
+		return " "
+	case before.T == lex.OpenParen || before.T == lex.OpenSquare:
+		return ""
+	case before.T == lex.Exec:
+		return ""
+	case p.binaryOp(i) || p.binaryOp(prev):
+		return " "
+	case before.T == lex.Minus || before.T == lex.Bang:
+		// unary operators are kept next to their operand
+		return ""
+	case before.T == lex.CloseSquare && prev > 0 && p.arrayType(prev-1):
+		// the element type of an array type
+		return ""
+	case tok.T == lex.OpenSquare && before.T == lex.Identifyer && p.arrayType(i):
+		// the type of a parameter written without a colon
+		return " "
+	case tok.T == lex.OpenSquare &&
+		(before.T == lex.Identifyer || before.T == lex.CloseSquare || before.T == lex.CloseParen):
+		return ""
+	default:
+		return original(before, tok)
+	}
+}
+
+// tightColon returns true if the colon at i assigns a type in a declaration like name:type
+func (p *printer) tightColon(i int) bool {
+	if i == 0 || i+1 >= len(p.tokens) {
+		return false
+	}
+
+	if p.tokens[i-1].T != lex.Identifyer || !p.entryStart(i-1) {
+		return false
+	}
+
+	if len(p.stack) > 0 && p.stack[len(p.stack)-1].literal {
+		return false
+	}
+
+	switch p.tokens[i+1].T {
+	case lex.OpenParen, lex.LessThan,
+		lex.StringType, lex.IntType, lex.BoolType, lex.CmdType:
+		return true
+	case lex.OpenSquare:
+		return i+2 < len(p.tokens) && p.tokens[i+2].T == lex.CloseSquare
+	case lex.Identifyer:
+		if p.tokens[i+1].Value == "fn" && i+2 < len(p.tokens) && p.tokens[i+2].T == lex.OpenParen {
+			return true
+		}
+		return i+2 >= len(p.tokens) || p.entryEnd(i+2)
+	default:
+		return false
+	}
+}
+
+// arrayType returns true if the open square at i starts an array type rather than an index or a cmd without arguments
+func (p *printer) arrayType(i int) bool {
+	return i+2 < len(p.tokens) && p.tokens[i+1].T == lex.CloseSquare && startsType(p.tokens[i+2])
+}
+
+// startsType returns true if the token can be the first token of a type
+func startsType(tok lex.Token) bool {
+	switch tok.T {
+	case lex.StringType, lex.IntType, lex.BoolType, lex.CmdType,
+		lex.Identifyer, lex.OpenParen, lex.LessThan, lex.OpenSquare:
+		return true
+	default:
+		return false
+	}
+}
+
+// assignColon returns true if the colon after the token at prev assigns to a variable, struct field or array element
+//
+//	x : 5
+//	me.x : 5
+//	names[0] : "a"
+func (p *printer) assignColon(prev int) bool {
+	switch p.inner() {
+	case ")", "]", ">":
+		return false
+	}
+	if len(p.stack) > 0 && p.stack[len(p.stack)-1].literal {
+		return false
+	}
+
+	start := p.target(prev)
+	return start >= 0 && p.entryStart(start) && !p.tightColon(prev+1)
+}
+
+// target returns the index of the first token in the variable, field or index expression that ends at i
+// it returns -1 if the tokens can not be assigned to
+func (p *printer) target(i int) int {
+	for i >= 0 {
+		switch p.tokens[i].T {
+		case lex.Identifyer:
+			if i < 2 || p.tokens[i-1].T != lex.Dot {
+				return i
+			}
+			i -= 2
+		case lex.CloseSquare:
+			i = p.opener(i) - 1
+		default:
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// opener returns the index of the bracket that opens the bracket closed at i, or -1 if it is not opened
+func (p *printer) opener(i int) int {
+	depth := 0
+	for ; i >= 0; i-- {
+		switch p.tokens[i].T {
+		case lex.CloseParen, lex.CloseSquare, lex.CloseBrace:
+			depth++
+		case lex.OpenParen, lex.OpenSquare, lex.OpenBrace:
+			depth--
+		}
+		if depth == 0 {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// binaryOp returns true if the token at i is an operator between two operands
+//
+//	a + b
+//	i < len(xs)
+func (p *printer) binaryOp(i int) bool {
+	switch p.tokens[i].T {
+	case lex.Plus, lex.Minus, lex.Star, lex.Slash, lex.Percent, lex.Power,
+		lex.And, lex.Or, lex.DoubleEqual, lex.NotEqual, lex.LessEqual, lex.GreaterEqual,
+		lex.PlusEqual, lex.MinusEqual, lex.StarEqual, lex.SlashEqual, lex.PercentEqual:
+		return p.operand(i - 1)
+	case lex.LessThan:
+		return p.operand(i - 1)
+	case lex.GreaterThan:
+		return p.operand(i-1) && !p.closesType(i)
+	default:
+		return false
+	}
+}
+
+// operand returns true if the token at i ends a value
+// a paren that closes a function signature like ()<int> ends a type instead
+func (p *printer) operand(i int) bool {
+	if i < 0 || !lex.IsOperand(p.tokens[i]) {
+		return false
+	}
+
+	return p.tokens[i].T != lex.CloseParen || !p.signature(i)
+}
+
+// signature returns true if the paren at i closes the parameters of a function signature
+func (p *printer) signature(i int) bool {
+	open := p.opener(i)
+	if open < 0 {
+		return false
+	}
+	if open > 0 && p.tokens[open-1].Value == "fn" {
+		return true
+	}
+	if open+1 == i {
+		// empty parens after a value call it
+		return open == 0 || !lex.IsOperand(p.tokens[open-1])
+	}
+
+	// every parameter has a type
+	depth := 0
+	for j := open + 1; j < i; j++ {
+		switch p.tokens[j].T {
+		case lex.OpenParen, lex.OpenSquare, lex.OpenBrace:
+			depth++
+		case lex.CloseParen, lex.CloseSquare, lex.CloseBrace:
+			depth--
+		case lex.Colon:
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// closesType returns true if the > at i closes a struct type or the return types of a function signature
+func (p *printer) closesType(i int) bool {
+	depth := 0
+	for j := i - 1; j >= 0; j-- {
+		switch p.tokens[j].T {
+		case lex.GreaterThan:
+			depth++
+		case lex.LessThan:
+			if p.binaryOp(j) {
+				continue
+			}
+			if depth == 0 {
+				return true
+			}
+			depth--
+		case lex.SemiColon, lex.OpenBrace, lex.CloseBrace:
+			return false
+		}
+	}
+
+	return false
+}
+
+// entryStart returns true if the token at i is the first token in a statement, field or argument
+func (p *printer) entryStart(i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	switch prev := p.tokens[i-1]; prev.T {
+	case lex.SemiColon, lex.Comma, lex.Comment, lex.OpenBrace, lex.OpenParen, lex.LessThan:
+		return true
+	default:
+		return false
+	}
+}
+
+// entryEnd returns true if the token at i ends a statement, field or argument
+func (p *printer) entryEnd(i int) bool {
+	switch tok := p.tokens[i]; tok.T {
+	case lex.SemiColon, lex.Comma, lex.Comment, lex.Colon,
+		lex.CloseBrace, lex.CloseParen, lex.CloseSquare, lex.GreaterThan:
+		return true
+	default:
+		return false
+	}
+}
+
+// brackets maps each opening bracket to its closing bracket
+var brackets = map[lex.TokType]string{
+	lex.OpenParen:  ")",
+	lex.OpenSquare: "]",
+	lex.OpenBrace:  "}",
+}
+
+// text returns the source text for a token
+func text(tok lex.Token) string {
+	switch tok.T {
+	case lex.String:
+		return `"` + tok.Value + `"`
+	case lex.Comment:
+		return "#" + strings.TrimRight(tok.Value, " \t\r")
+	default:
+		return tok.Value
+	}
+}
+
+// original returns the spacing that was used between the tokens in the original source
+func original(before, tok lex.Token) string {
+	if startCol(tok) > endCol(before) {
+		return " "
+	}
+
+	return ""
+}
+
+// startCol returns the column the token starts at in the original source
+func startCol(tok lex.Token) int {
+	if tok.T == lex.String {
+		// string tokens are positioned at the first character after the opening quote
+		return tok.ColNumber - 1
+	}
+
+	return tok.ColNumber
+}
+
+// endLine returns the line the token ends on in the original source
+func endLine(tok lex.Token) int {
+	return tok.LineNumber + strings.Count(text(tok), "\n")
+}
+
+// endCol returns the column just after the token in the original source
+func endCol(tok lex.Token) int {
+	src := text(tok)
+	if i := strings.LastIndex(src, "\n"); i >= 0 {
+		return utf8.RuneCountInString(src[i+1:])
+	}
+
+	return startCol(tok) + utf8.RuneCountInString(src)
+}
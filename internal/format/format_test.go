@@ -0,0 +1,143 @@
+package format
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjatkin/blow-k/internal/lex"
+	"github.com/bjatkin/blow-k/internal/tok"
+)
+
+// formatSrc runs the src code through the tokenizer, lexer and formatter
+func formatSrc(t *testing.T, src string) string {
+	srcFile := filepath.Join(t.TempDir(), "src.bk")
+	if err := os.WriteFile(srcFile, []byte(src), 0644); err != nil {
+		t.Fatalf("formatSrc() failed to write src file %v", err)
+	}
+
+	tokens, err := tok.NewClient().Tokenize(srcFile)
+	if err != nil {
+		t.Fatalf("formatSrc() unexpected error %v", err)
+	}
+
+	lexTokens, err := lex.NewClient().Lex(tokens)
+	if err != nil {
+		t.Fatalf("formatSrc() unexpected error %v", err)
+	}
+
+	return string(NewClient().Format(lexTokens))
+}
+
+func TestClient_Format(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"declarations",
+			"a : string\nb:string:\"hi\"\nc::\"yo\"\n",
+			"a:string\nb:string: \"hi\"\nc :: \"yo\"\n",
+		},
+		{
+			"comments are kept",
+			"#say \"hi\"\nimport   echo # it's an import\n\n\n# end",
+			"#say \"hi\"\nimport echo # it's an import\n\n# end\n",
+		},
+		{
+			"blocks are indented",
+			"main :(args []string): {\n\t$echo[ \"hello\",args[0] ]\n  }\n",
+			"main:(args []string): {\n    $echo[\"hello\", args[0]]\n}\n",
+		},
+		{
+			"array types",
+			"a:[] [] int\nb : []v2\nc:cmd: echo[]\nf:(xs [] fn(int)): {}\n",
+			"a:[][]int\nb:[]v2\nc:cmd: echo[]\nf:(xs []fn(int)): {}\n",
+		},
+		{
+			"struct literals",
+			"a:v2: {x:1,y:2}\nb:v2:{}\n",
+			"a:v2: { x: 1, y: 2 }\nb:v2: {}\n",
+		},
+		{
+			"slices and assignment",
+			"b :: a[ 1 : ]\nb[0] : \"test\"\n",
+			"b :: a[1:]\nb[0] : \"test\"\n",
+		},
+		{
+			"assignments and binary operators",
+			"f:(): {\n    b: \"x\"\n    me.x:me.x+1\n    if b==\"x\" {\n        n:int: -1\n        xs[n]:3-n*2\n    }\n    ok :: !a&&x<=len(xs)||f()<2\n}\n",
+			"f:(): {\n    b : \"x\"\n    me.x : me.x + 1\n    if b == \"x\" {\n        n:int: -1\n        xs[n] : 3 - n * 2\n    }\n    ok :: !a && x <= len(xs) || f() < 2\n}\n",
+		},
+		{
+			"operators",
+			"c::1\nc++\nf:()||<string>:{}\n",
+			"c :: 1\nc++\nf:()||<string>: {}\n",
+		},
+		{
+			"fn types",
+			"h : fn(int)<int>: adder(1)\nf:(g fn(string)<string>){}\n",
+			"h:fn(int)<int>: adder(1)\nf:(g fn(string)<string>) {}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatSrc(t, tt.src)
+			if got != tt.want {
+				t.Fatalf("Format() = %q, want %q", got, tt.want)
+			}
+
+			// formatting must be stable
+			if again := formatSrc(t, got); again != got {
+				t.Errorf("Format() is not idempotent, got %q, want %q", again, got)
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			"no changes",
+			"a\nb\n",
+			"a\nb\n",
+			"",
+		},
+		{
+			"single change",
+			"a\nb\nc\n",
+			"a\nB\nc\n",
+			"--- f.bk\n+++ f.bk (formatted)\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			"separate hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			"--- f.bk\n+++ f.bk (formatted)\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			"missing newline at the end of the file",
+			"x :: 1 # trailing",
+			"x :: 1 # trailing\n",
+			"--- f.bk\n+++ f.bk (formatted)\n@@ -1,1 +1,1 @@\n" +
+				"-x :: 1 # trailing\n\\ No newline at end of file\n+x :: 1 # trailing\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff("f.bk", []byte(tt.before), []byte(tt.after)); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// arrays are stored in bash indexed arrays, elements are always kept at the indexes 0 to len - 1
+//
+//	a=( "hi" "hello" )
+
+// genArrayLit returns the right hand side of an assignment for an array literal
+func (g *generator) genArrayLit(lit *lang.ArrayLit) (string, error) {
+	if len(lit.Elems) == 0 {
+		return "()", nil
+	}
+
+	var words []string
+	for _, elem := range lit.Elems {
+		word, err := g.genWord(elem)
+		if err != nil {
+			return "", err
+		}
+		words = append(words, word)
+	}
+
+	return fmt.Sprintf("( %s )", strings.Join(words, " ")), nil
+}
+
+// genSlice returns the right hand side of an assignment for a sub array
+func (g *generator) genSlice(s *lang.Slice) (string, error) {
+	typ, err := g.typeOf(s)
+	if err != nil {
+		return "", err
+	}
+	if isNested(typ) {
+		return "", genError(s, "nested arrays can not be stored in a bash array")
+	}
+	if lang.IsCmd(typ) {
+		return g.genCmdSlice(s)
+	}
+
+	name, err := g.arrayVar(s.X)
+	if err != nil {
+		return "", err
+	}
+
+	var low, high string
+	if s.Low != nil {
+		low, err = g.genArith(s.Low)
+		if err != nil {
+			return "", err
+		}
+		// a leading - would be read as the :- default value expansion
+		if strings.HasPrefix(low, "-") {
+			low = "(" + low + ")"
+		}
+	}
+	if s.High != nil {
+		high, err = g.genArith(s.High)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case s.Low == nil && s.High == nil:
+		return fmt.Sprintf(`( "${%s[@]}" )`, name), nil
+	case s.High == nil:
+		return fmt.Sprintf(`( "${%s[@]:%s}" )`, name, low), nil
+	case s.Low == nil:
+		return fmt.Sprintf(`( "${%s[@]:0:%s}" )`, name, high), nil
+	default:
+		return fmt.Sprintf(`( "${%s[@]:%s:%s - %s}" )`, name, low, high, low), nil
+	}
+}
+
+// elemRef returns the bash array and subscript that hold an array element
+// nested arrays and array fields of structs are stored under keys in an associative array
+func (g *generator) elemRef(idx *lang.Index) (string, string, lang.Node, error) {
+	xType, err := g.typeOf(idx.X)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if lang.IsCmd(xType) {
+		name, err := g.cmdVar(idx.X)
+		if err != nil {
+			return "", "", nil, err
+		}
+		i, err := g.genArith(idx.Index)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return name, argIndex(i), &lang.TypeName{Name: "string"}, nil
+	}
+
+	arr, ok := xType.(*lang.ArrayType)
+	if !ok {
+		return "", "", nil, genError(idx, "only arrays and cmds can be indexed")
+	}
+
+	name, prefix, _, ok, err := g.assocArray(idx.X)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	i, err := g.genArith(idx.Index)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if ok {
+		return name, prefix + assocIndex(i), arr.Elem, nil
+	}
+
+	if ident, ok := idx.X.(*lang.Ident); ok {
+		return ident.Name, i, arr.Elem, nil
+	}
+
+	name, err = g.arrayVar(idx.X)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return name, i, arr.Elem, nil
+}
+
+// assocIndex returns the part of an associative array key for an arithmetic index
+// associative array keys are not arithmetic so anything but a plain number is expanded first
+func assocIndex(i string) string {
+	if strings.Trim(i, "0123456789") == "" {
+		return i
+	}
+
+	return "$(( " + i + " ))"
+}
+
+// genAppend writes an append of a single value or of every element of an array
+func (g *generator) genAppend(a *lang.Append) error {
+	xType, err := g.typeOf(a.X)
+	if err != nil {
+		return err
+	}
+	if lang.IsCmd(xType) {
+		return g.genCmdAppend(a)
+	}
+	arr, ok := xType.(*lang.ArrayType)
+	if !ok {
+		return genError(a, "values can only be appended to arrays and cmds")
+	}
+
+	valueType, err := g.typeOf(a.Value)
+	if err != nil {
+		return err
+	}
+	if !lang.SameType(arr.Elem, valueType) && !lang.SameType(arr, valueType) {
+		return genError(a.Value, "mismatched array types")
+	}
+
+	name, prefix, _, ok, err := g.assocArray(a.X)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		ident, ok := a.X.(*lang.Ident)
+		if !ok {
+			return genError(a, "values can only be appended to array variables")
+		}
+
+		var value string
+		switch {
+		case lang.IsArray(valueType):
+			value, err = g.genValue(a.Value)
+		default:
+			value, err = g.genWord(a.Value)
+			value = "( " + value + " )"
+		}
+		if err != nil {
+			return err
+		}
+		g.line("%s+=%s", ident.Name, value)
+		return nil
+	}
+
+	length := fmt.Sprintf("%s[%slen]", name, prefix)
+	switch {
+	case !lang.IsArray(valueType):
+		word, err := g.genWord(a.Value)
+		if err != nil {
+			return err
+		}
+		g.line("%s[%s${%s}]=%s", name, prefix, length, word)
+		g.line("%s=$(( ${%s} + 1 ))", length, length)
+	case !lang.IsArray(arr.Elem):
+		src, err := g.arrayVar(a.Value)
+		if err != nil {
+			return err
+		}
+		g.line(`for _bk_v in "${%s[@]}"; do`, src)
+		g.line(`    %s[%s${%s}]="${_bk_v}"`, name, prefix, length)
+		g.line("    %s=$(( ${%s} + 1 ))", length, length)
+		g.line("done")
+	case lang.SameType(arr.Elem, valueType):
+		// a single row is stored under the next index
+		var items []string
+		var post []func()
+		if err := g.arrayItems(&items, &post, name, prefix+"$_bk_n,", arr.Elem.(*lang.ArrayType), a.Value); err != nil {
+			return err
+		}
+		g.line("_bk_n=${%s}", length)
+		g.writeItems(name+"+=", items, post)
+		g.line("%s=$(( _bk_n + 1 ))", length)
+	default:
+		src, srcPrefix, _, _, err := g.assocArray(a.Value)
+		if err != nil {
+			return err
+		}
+		srcLength := fmt.Sprintf("${%s[%slen]}", src, srcPrefix)
+		g.line("_bk_n=${%s}", length)
+		g.copyRows(name, prefix, "_bk_n", src, srcPrefix, "0", srcLength)
+		g.line("%s=$(( _bk_n + %s ))", length, srcLength)
+	}
+
+	return nil
+}
+
+// genPop removes the last element of an array and returns the name of the variable that holds the removed value
+// the value is only kept when it is used
+func (g *generator) genPop(p *lang.Pop, keep bool) (string, error) {
+	xType, err := g.typeOf(p.X)
+	if err != nil {
+		return "", err
+	}
+	if lang.IsCmd(xType) {
+		return g.genCmdPop(p, keep)
+	}
+	arr, ok := xType.(*lang.ArrayType)
+	if !ok {
+		return "", genError(p, "only arrays and cmds can be popped")
+	}
+
+	name, prefix, _, ok, err := g.assocArray(p.X)
+	if err != nil {
+		return "", err
+	}
+
+	tmp := ""
+	if !ok {
+		ident, ok := p.X.(*lang.Ident)
+		if !ok {
+			return "", genError(p, "only array variables can be popped")
+		}
+
+		g.line("_bk_last=$(( ${#%s[@]} - 1 ))", ident.Name)
+		if keep {
+			tmp = g.tmpVar()
+			g.line(`%s%s="${%s[_bk_last]}"`, g.local(""), tmp, ident.Name)
+		}
+		g.line(`%s=( "${%s[@]:0:_bk_last}" )`, ident.Name, ident.Name)
+		return tmp, nil
+	}
+
+	g.line("_bk_last=$(( ${%s[%slen]} - 1 ))", name, prefix)
+	last := prefix + "$_bk_last"
+	switch {
+	case !lang.IsArray(arr.Elem):
+		if keep {
+			tmp = g.tmpVar()
+			g.line(`%s%s="${%s[%s]}"`, g.local(""), tmp, name, last)
+		}
+		g.line(`unset "%s[%s]"`, name, last)
+	case isNested(arr.Elem):
+		if keep {
+			tmp = g.tmpVar()
+			g.line("%s%s=()", g.local("-A"), tmp)
+			g.copyKeys(tmp, "", name, last+",")
+		}
+		g.clearKeys(name, last+",")
+	default:
+		if keep {
+			tmp = g.copyAssoc(name, last+",")
+		}
+		g.clearKeys(name, last+",")
+	}
+	g.line("%s[%slen]=$_bk_last", name, prefix)
+
+	return tmp, nil
+}
+
+// genLen returns a bash word for the length of an array
+// nested arrays keep their length under the len key, other arrays are counted by bash
+func (g *generator) genLen(l *lang.Len) (string, error) {
+	name, prefix, _, ok, err := g.assocArray(l.X)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return fmt.Sprintf(`"${%s[%slen]}"`, name, prefix), nil
+	}
+
+	name, err = g.arrayVar(l.X)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`"${#%s[@]}"`, name), nil
+}
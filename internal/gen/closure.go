@@ -0,0 +1,235 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// fn values are stored in bash indexed arrays, the name of the bash function is the first element
+// followed by the values captured by the function
+//
+//	fn=( anon_1 "world" )
+//
+// calling a fn value expands the array so the captured values are passed before the arguments
+//
+//	"${fn[@]}" "hello"
+//
+// variables that outlive the function they are declared in are read when the function is called.
+// variables of other functions are captured by reference, the fn value holds the number of the call
+// that created it followed by the captured values
+//
+//	fn=( anon_1 "${_bk_frame_outer}" "world" )
+//
+// while that call is the innermost running call of the owner the function uses the variables by name,
+// each captured variable has a name no other function declares so it can only find the owner's variable.
+// once the call has returned the function starts from the values the variables had when the literal was
+// evaluated on every call, changes made after that point are not seen and changes made by the function
+// are not kept. the same is true while a recursive call of the owner hides the call that created the fn value
+// captured arrays are quoted into a single word and evaluated back into an array by the function
+
+// isList returns true if values of the type are stored in a bash indexed array
+func isList(typ lang.Node) bool {
+	return (lang.IsArray(typ) && !isNested(typ)) || lang.IsCmd(typ) || lang.IsFn(typ)
+}
+
+// genFnValue returns the right hand side of an assignment for a fn value
+func (g *generator) genFnValue(node lang.Node) (string, error) {
+	switch v := node.(type) {
+	case *lang.Func:
+		return g.genClosure(v, "")
+	case *lang.Ident:
+		if g.scope.isFunc(v.Name) {
+			return fmt.Sprintf("( %s )", v.Name), nil
+		}
+		return fmt.Sprintf(`( "${%s[@]}" )`, v.Name), nil
+	case *lang.Call:
+		ret, _, err := g.genCallValue(v)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`( "${%s[@]}" )`, ret), nil
+	default:
+		return "", invalidNode(node)
+	}
+}
+
+// fnVar returns the name of a bash array that holds the fn value
+// values that are not already stored in a variable are copied into a temporary variable
+func (g *generator) fnVar(node lang.Node) (string, error) {
+	if ident, ok := node.(*lang.Ident); ok && !g.scope.isFunc(ident.Name) {
+		return ident.Name, nil
+	}
+
+	value, err := g.genFnValue(node)
+	if err != nil {
+		return "", err
+	}
+
+	tmp := g.tmpVar()
+	g.line("%s%s=%s", g.local("-a"), tmp, value)
+	return tmp, nil
+}
+
+// genClosure writes a bash function for a function literal and returns the fn value that calls it
+// self is the name of a function declared inside of another function, it is empty for function literals
+func (g *generator) genClosure(fn *lang.Func, self string) (string, error) {
+	g.anon++
+	name := fmt.Sprintf("anon_%d", g.anon)
+
+	captures, err := g.captures(fn, self)
+	if err != nil {
+		return "", err
+	}
+
+	// the fn value is only rebuilt inside the function if the function calls itself
+	recursive := false
+	for _, ident := range freeIdents(fn.Body) {
+		recursive = recursive || ident.Name == self
+	}
+	if !recursive {
+		self = ""
+	}
+
+	if err := g.genFuncWith(name, fn, nil, captures, self); err != nil {
+		return "", err
+	}
+
+	return g.fnValue(name, fmt.Sprintf(`"${%s}"`, frameVar(g.fn.name)), captures), nil
+}
+
+// fnValue returns the fn value that calls the bash function with the current values of the captured variables
+// frame is the word that numbers the call of the function that owns the captured variables
+func (g *generator) fnValue(name, frame string, captures []string) string {
+	words := []string{name}
+	if len(captures) > 0 {
+		words = append(words, frame)
+	}
+	for _, capture := range captures {
+		typ, _ := g.scope.Lookup(capture)
+		if isList(typ) {
+			words = append(words, fmt.Sprintf(`"$( (( ${#%s[@]} )) && printf '%%q ' "${%s[@]}" )"`, capture, capture))
+			continue
+		}
+		words = append(words, fmt.Sprintf(`"${%s}"`, capture))
+	}
+
+	return fmt.Sprintf("( %s )", strings.Join(words, " "))
+}
+
+// captures returns the variables a function literal uses that are local to an enclosing function
+// the function's own name is never captured since the function rebuilds its own fn value
+func (g *generator) captures(fn *lang.Func, self string) ([]string, error) {
+	params := map[string]bool{self: self != ""}
+	for _, param := range fn.Type.Params {
+		params[param.Name] = true
+	}
+	for _, input := range fn.Type.Inputs {
+		params[input.Name] = true
+	}
+
+	var captures []string
+	seen := make(map[string]bool)
+	for _, ident := range freeIdents(fn.Body) {
+		if params[ident.Name] || seen[ident.Name] {
+			continue
+		}
+		seen[ident.Name] = true
+
+		owner, ok := g.scope.owner(ident.Name)
+		if !ok || !owner.local || owner.funcs[ident.Name] {
+			continue
+		}
+
+		typ, _ := owner.Declared(ident.Name)
+		if isNested(typ) || g.isStruct(typ) {
+			return nil, genError(ident, ident.SourceName()+" is declared in another function and structs and nested arrays can not be captured")
+		}
+
+		captures = append(captures, ident.Name)
+	}
+
+	// the owner of the captured variables is always the function the literal is declared in
+	if len(captures) > 0 {
+		g.fn.frame = true
+	}
+
+	return captures, nil
+}
+
+// freeIdents returns every identifier that references a variable in the node, in the order they are used
+func freeIdents(node lang.Node) []*lang.Ident {
+	switch v := node.(type) {
+	case nil:
+		return nil
+	case *lang.Ident:
+		return []*lang.Ident{v}
+	case *lang.Field:
+		return freeIdents(v.X)
+	case *lang.KeyValue:
+		return freeIdents(v.Value)
+	case *lang.Func:
+		return freeIdents(v.Body)
+	}
+
+	var idents []*lang.Ident
+	for _, child := range node.Children() {
+		idents = append(idents, freeIdents(child)...)
+	}
+
+	return idents
+}
+
+// genCallValue writes a call and returns the name of the variable that holds its return value
+func (g *generator) genCallValue(call *lang.Call) (string, *funcRef, error) {
+	line, ref, err := g.genCall(call)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(ref.sig.Returns) == 0 {
+		return "", nil, genError(call, "function call has no value")
+	}
+
+	g.writeCall(line, ref)
+	return g.retRef(ref, "", ref.sig.Returns[0]), ref, nil
+}
+
+// retRef returns the name of the variable that holds a return value of a called function
+// the suffix selects one of several return values
+// functions called through a fn value are only known at run time so their return value is copied out of
+// the variable with the name of the function, every value of the same function literal shares that variable
+// return values are also copied when the statement makes another call that could overwrite them before they are used
+func (g *generator) retRef(ref *funcRef, suffix string, typ lang.Node) string {
+	src := retVar(ref.name) + suffix
+	if !ref.value && !g.copyRets {
+		return src
+	}
+
+	tmp := g.tmpVar()
+	switch {
+	case isList(typ) && ref.value:
+		g.line(`_bk_ref="${%s[0]}_ret%s[@]"`, ref.name, suffix)
+		g.line(`%s%s=( "${!_bk_ref}" )`, g.local("-a"), tmp)
+	case isList(typ):
+		g.line(`%s%s=( "${%s[@]}" )`, g.local("-a"), tmp, src)
+	case isNested(typ) || g.isStruct(typ):
+		if ref.value {
+			g.line(`%s%s="${%s[0]}_ret%s"`, g.local("-n"), tmp, ref.name, suffix)
+			if !g.copyRets {
+				return tmp
+			}
+			src, tmp = tmp, g.tmpVar()
+		}
+		g.line("%s%s=()", g.local("-A"), tmp)
+		g.copyKeys(tmp, "", src, "")
+	case ref.value:
+		g.line(`_bk_ref="${%s[0]}_ret%s"`, ref.name, suffix)
+		g.line(`%s%s="${!_bk_ref}"`, g.local(""), tmp)
+	default:
+		g.line(`%s%s="${%s}"`, g.local(""), tmp, src)
+	}
+
+	return tmp
+}
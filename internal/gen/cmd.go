@@ -0,0 +1,180 @@
+package gen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// cmds are stored in bash indexed arrays, the command is the first element followed by its arguments
+//
+//	hello=( echo "hello" "world" )
+//
+// indexes and slices only apply to the arguments so the command is always kept
+
+// cmdLit returns the cmd literal for a node
+func (g *generator) cmdLit(node lang.Node) (*lang.Cmd, bool) {
+	return g.scope.CmdLit(node, func(name string) bool {
+		_, ok := g.imports[name]
+		return ok
+	})
+}
+
+// genCmdLit returns the right hand side of an assignment for a cmd literal
+func (g *generator) genCmdLit(cmd *lang.Cmd) (string, error) {
+	name, ok := g.imports[cmd.Name.Name]
+	if !ok {
+		return "", genError(cmd.Name, cmd.Name.SourceName()+" is not an imported command")
+	}
+
+	words := []string{name}
+	for _, arg := range cmd.Args {
+		word, err := g.genWords(arg)
+		if err != nil {
+			return "", err
+		}
+		words = append(words, word)
+	}
+
+	return fmt.Sprintf("( %s )", strings.Join(words, " ")), nil
+}
+
+// genCmdValue returns the right hand side of an assignment for a cmd value
+func (g *generator) genCmdValue(node lang.Node) (string, error) {
+	if cmd, ok := g.cmdLit(node); ok {
+		return g.genCmdLit(cmd)
+	}
+
+	switch v := node.(type) {
+	case *lang.Ident:
+		return copyValue(&lang.TypeName{Name: "cmd"}, v.Name), nil
+	case *lang.Call:
+		ret, _, err := g.genCallValue(v)
+		if err != nil {
+			return "", err
+		}
+		return copyValue(&lang.TypeName{Name: "cmd"}, ret), nil
+	case *lang.Slice:
+		return g.genCmdSlice(v)
+	default:
+		return "", invalidNode(node)
+	}
+}
+
+// cmdVar returns the name of a bash array that holds the cmd value
+// values that are not already stored in a variable are copied into a temporary variable
+func (g *generator) cmdVar(node lang.Node) (string, error) {
+	if ident, ok := node.(*lang.Ident); ok {
+		return ident.Name, nil
+	}
+
+	value, err := g.genCmdValue(node)
+	if err != nil {
+		return "", err
+	}
+
+	tmp := g.tmpVar()
+	g.line("%s%s=%s", g.local("-a"), tmp, value)
+	return tmp, nil
+}
+
+// argIndex returns the index of the bash array element that holds an argument of a cmd
+func argIndex(i string) string {
+	if n, err := strconv.Atoi(i); err == nil {
+		return strconv.Itoa(n + 1)
+	}
+
+	return i + " + 1"
+}
+
+// genCmdSlice returns the right hand side of an assignment for a cmd with a sub set of its arguments
+func (g *generator) genCmdSlice(s *lang.Slice) (string, error) {
+	name, err := g.cmdVar(s.X)
+	if err != nil {
+		return "", err
+	}
+
+	var low, high string
+	if s.Low != nil {
+		low, err = g.genArith(s.Low)
+		if err != nil {
+			return "", err
+		}
+	}
+	if s.High != nil {
+		high, err = g.genArith(s.High)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case s.Low == nil && s.High == nil:
+		return fmt.Sprintf(`( "${%s[@]}" )`, name), nil
+	case s.High == nil:
+		return fmt.Sprintf(`( "${%s[0]}" "${%s[@]:%s}" )`, name, name, argIndex(low)), nil
+	case s.Low == nil:
+		return fmt.Sprintf(`( "${%s[@]:0:%s}" )`, name, argIndex(high)), nil
+	default:
+		return fmt.Sprintf(`( "${%s[0]}" "${%s[@]:%s:%s - %s}" )`, name, name, argIndex(low), high, low), nil
+	}
+}
+
+// genCmdAppend writes an append of a single argument or of every element of a string array to a cmd
+func (g *generator) genCmdAppend(a *lang.Append) error {
+	ident, ok := a.X.(*lang.Ident)
+	if !ok {
+		return genError(a, "arguments can only be appended to cmd variables")
+	}
+
+	typ, err := g.typeOf(a.Value)
+	if err != nil {
+		return err
+	}
+
+	var value string
+	switch {
+	case lang.IsString(typ):
+		value, err = g.genWord(a.Value)
+		value = "( " + value + " )"
+	case lang.SameType(typ, &lang.ArrayType{Elem: &lang.TypeName{Name: "string"}}):
+		value, err = g.genValue(a.Value)
+	default:
+		return genError(a.Value, "only strings can be appended to a cmd")
+	}
+	if err != nil {
+		return err
+	}
+
+	g.line("%s+=%s", ident.Name, value)
+	return nil
+}
+
+// genCmdPop removes the last argument of a cmd and returns the name of the variable that holds it
+// the command itself is never removed
+func (g *generator) genCmdPop(p *lang.Pop, keep bool) (string, error) {
+	ident, ok := p.X.(*lang.Ident)
+	if !ok {
+		return "", genError(p, "only cmd variables can be popped")
+	}
+
+	tmp := ""
+	if keep {
+		tmp = g.tmpVar()
+		g.line(`%s%s=""`, g.local(""), tmp)
+	}
+
+	g.line("_bk_last=$(( ${#%s[@]} - 1 ))", ident.Name)
+	g.line("if (( _bk_last > 0 )); then")
+	g.depth++
+	if keep {
+		g.line(`%s="${%s[_bk_last]}"`, tmp, ident.Name)
+	}
+	g.line(`%s=( "${%s[@]:0:_bk_last}" )`, ident.Name, ident.Name)
+	g.depth--
+	g.line("fi")
+
+	return tmp, nil
+}
@@ -0,0 +1,429 @@
+package gen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// genValue returns the right hand side of an assignment for the given value node
+// any code needed to compute the value is written before the value is returned
+func (g *generator) genValue(node lang.Node) (string, error) {
+	// literals are generated without their type so empty arrays can be used with any declared type
+	if lit, ok := node.(*lang.ArrayLit); ok {
+		return g.genArrayLit(lit)
+	}
+
+	typ, err := g.typeOf(node)
+	if err != nil {
+		return "", err
+	}
+
+	if lang.IsCmd(typ) {
+		return g.genCmdValue(node)
+	}
+	if lang.IsFn(typ) {
+		return g.genFnValue(node)
+	}
+	if !lang.IsArray(typ) {
+		return g.genWord(node)
+	}
+	if isNested(typ) {
+		return "", genError(node, "nested arrays can not be stored in a bash array")
+	}
+
+	switch v := node.(type) {
+	case *lang.Ident:
+		return copyValue(typ, v.Name), nil
+	case *lang.Call:
+		ret, _, err := g.genCallValue(v)
+		if err != nil {
+			return "", err
+		}
+		return copyValue(typ, ret), nil
+	case *lang.Slice:
+		return g.genSlice(v)
+	case *lang.Pop:
+		tmp, err := g.genPop(v, true)
+		if err != nil {
+			return "", err
+		}
+		return copyValue(typ, tmp), nil
+	}
+
+	name, prefix, _, ok, err := g.assocArray(node)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", invalidNode(node)
+	}
+
+	return copyValue(typ, g.copyAssoc(name, prefix)), nil
+}
+
+// genWord returns a single double quoted bash word for a scalar value
+func (g *generator) genWord(node lang.Node) (string, error) {
+	switch v := node.(type) {
+	case *lang.String:
+		return quote(v.Value), nil
+	case *lang.Int:
+		return v.Value, nil
+	case *lang.Bool:
+		return strconv.FormatBool(v.Value), nil
+	case *lang.Binary:
+		return g.genBinaryWord(v)
+	case *lang.Unary:
+		return g.genBoolWord(v)
+	case *lang.Ident:
+		typ, ok := g.scope.Lookup(v.Name)
+		if !ok {
+			return "", undefined(v)
+		}
+		if lang.IsArray(typ) || lang.IsCmd(typ) || lang.IsFn(typ) || g.isStruct(typ) {
+			return "", genError(v, v.SourceName()+" can not be used as a single value")
+		}
+		return fmt.Sprintf(`"${%s}"`, v.Name), nil
+	case *lang.Field:
+		name, key, typ, err := g.fieldRef(v)
+		if err != nil {
+			return "", err
+		}
+		if lang.IsArray(typ) || lang.IsCmd(typ) || g.isStruct(typ) {
+			return "", genError(v, key+" can not be used as a single value")
+		}
+		return fmt.Sprintf(`"${%s[%s]}"`, name, key), nil
+	case *lang.Call:
+		ret, _, err := g.genCallValue(v)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`"${%s}"`, ret), nil
+	case *lang.Exec:
+		cmd, err := g.genExec(v)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`"$( %s )"`, cmd), nil
+	case *lang.Pipeline:
+		cmd, err := g.genPipeline(v)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`"$( %s )"`, cmd), nil
+	case *lang.Index:
+		if _, ok := g.cmdLit(v); ok {
+			return "", genError(v, "a cmd can not be used as a single value")
+		}
+		name, sub, typ, err := g.elemRef(v)
+		if err != nil {
+			return "", err
+		}
+		if lang.IsArray(typ) || g.isStruct(typ) {
+			return "", genError(v, "this element can not be used as a single value")
+		}
+		return fmt.Sprintf(`"${%s[%s]}"`, name, sub), nil
+	case *lang.Pop:
+		tmp, err := g.genPop(v, true)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`"${%s}"`, tmp), nil
+	case *lang.Len:
+		return g.genLen(v)
+	default:
+		return "", invalidNode(node)
+	}
+}
+
+// genWords returns the bash words for a value, arrays are expanded into one word per element
+func (g *generator) genWords(node lang.Node) (string, error) {
+	typ, err := g.typeOf(node)
+	if err != nil {
+		return "", err
+	}
+
+	if lang.IsCmd(typ) {
+		name, err := g.cmdVar(node)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`"${%s[@]}"`, name), nil
+	}
+
+	if !lang.IsArray(typ) {
+		return g.genWord(node)
+	}
+
+	if isNested(typ) {
+		name, prefix, arr, _, err := g.assocArray(node)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`"${%s[@]}"`, g.flatten(name, prefix, arr)), nil
+	}
+
+	name, err := g.arrayVar(node)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`"${%s[@]}"`, name), nil
+}
+
+// genExec returns the command line for an exec
+func (g *generator) genExec(e *lang.Exec) (string, error) {
+	cmd, ok := e.Expr.(*lang.Cmd)
+	if !ok {
+		return "", invalidNode(e.Expr)
+	}
+
+	var words []string
+	if typ, ok := g.scope.Lookup(cmd.Name.Name); ok {
+		// cmd variables are expanded so every argument stays a separate word
+		if !lang.IsCmd(typ) {
+			return "", genError(cmd.Name, cmd.Name.SourceName()+" is not a cmd")
+		}
+		words = append(words, fmt.Sprintf(`"${%s[@]}"`, cmd.Name.Name))
+	} else {
+		name, ok := g.imports[cmd.Name.Name]
+		if !ok {
+			return "", genError(cmd.Name, cmd.Name.SourceName()+" is not an imported command")
+		}
+		words = append(words, name)
+	}
+
+	for _, arg := range cmd.Args {
+		word, err := g.genWords(arg)
+		if err != nil {
+			return "", err
+		}
+		words = append(words, word)
+	}
+
+	return strings.Join(words, " "), nil
+}
+
+// copyValue returns the right hand side of an assignment that copies the named variable
+func copyValue(typ lang.Node, name string) string {
+	if lang.IsArray(typ) || lang.IsCmd(typ) || lang.IsFn(typ) {
+		return fmt.Sprintf(`( "${%s[@]}" )`, name)
+	}
+
+	return fmt.Sprintf(`"${%s}"`, name)
+}
+
+// genBinaryWord returns a single bash word for the result of a binary expression
+// strings are joined by placing the words next to each other
+func (g *generator) genBinaryWord(b *lang.Binary) (string, error) {
+	typ, err := g.typeOf(b)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case lang.IsInt(typ):
+		arith, err := g.genArith(b)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf(`"$(( %s ))"`, arith), nil
+	case lang.IsString(typ) && b.Op == "+":
+		x, err := g.genWord(b.X)
+		if err != nil {
+			return "", err
+		}
+		y, err := g.genWord(b.Y)
+		if err != nil {
+			return "", err
+		}
+		return x + y, nil
+	case lang.IsBool(typ):
+		return g.genBoolWord(b)
+	default:
+		return "", genError(b, "the "+b.Op+" operator is not defined for these values")
+	}
+}
+
+// genArith returns a bash arithmetic expression for an int value
+func (g *generator) genArith(node lang.Node) (string, error) {
+	typ, err := g.typeOf(node)
+	if err != nil {
+		return "", err
+	}
+	if !lang.IsInt(typ) {
+		return "", genError(node, "expected an int value")
+	}
+
+	switch v := node.(type) {
+	case *lang.Int:
+		return v.Value, nil
+	case *lang.Ident:
+		return v.Name, nil
+	case *lang.Index:
+		// elements of indexed arrays can be referenced by name in arithmetic
+		if x, ok := v.X.(*lang.Ident); ok && !g.isNestedVar(x) {
+			i, err := g.genArith(v.Index)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s[%s]", x.Name, i), nil
+		}
+		word, err := g.genWord(node)
+		if err != nil {
+			return "", err
+		}
+		return strings.Trim(word, `"`), nil
+	case *lang.Binary:
+		// the right operand binds tighter so a - (b - c) keeps its parens
+		xPrec, yPrec := arithPrec[v.Op], arithPrec[v.Op]+1
+		if v.Op == "**" {
+			// ** is right associative so it is the left operand that needs parens
+			xPrec, yPrec = yPrec, xPrec
+		}
+
+		x, err := g.genOperand(v.X, xPrec)
+		if err != nil {
+			return "", err
+		}
+		y, err := g.genOperand(v.Y, yPrec)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s %s %s", x, v.Op, y), nil
+	default:
+		word, err := g.genWord(node)
+		if err != nil {
+			return "", err
+		}
+		return strings.Trim(word, `"`), nil
+	}
+}
+
+// arithPrec is the precedence of the bash arithmetic operators, higher values bind tighter
+var arithPrec = map[string]int{
+	"+": 1, "-": 1,
+	"*": 2, "/": 2, "%": 2,
+	"**": 3,
+}
+
+// genOperand returns the arithmetic expression for an operand
+// nested expressions that bind looser than prec are wrapped in parens
+func (g *generator) genOperand(node lang.Node, prec int) (string, error) {
+	arith, err := g.genArith(node)
+	if err != nil {
+		return "", err
+	}
+
+	if b, ok := node.(*lang.Binary); ok && arithPrec[b.Op] < prec {
+		return "( " + arith + " )", nil
+	}
+
+	return arith, nil
+}
+
+// genTest returns a bash test command for a condition
+// int comparisons use (( )), string and bool comparisons use [[ ]] and && or || join separate tests
+func (g *generator) genTest(node lang.Node) (string, error) {
+	switch v := node.(type) {
+	case *lang.Bool:
+		// true and false are already bash commands
+		return strconv.FormatBool(v.Value), nil
+	case *lang.Binary:
+		switch {
+		case lang.IsLogical(v.Op):
+			x, err := g.genTest(v.X)
+			if err != nil {
+				return "", err
+			}
+			y, err := g.genTest(v.Y)
+			if err != nil {
+				return "", err
+			}
+
+			// bash gives && and || the same precedence so nested groups need braces
+			if b, ok := v.X.(*lang.Binary); ok && lang.IsLogical(b.Op) && b.Op != v.Op {
+				x = "{ " + x + "; }"
+			}
+			if b, ok := v.Y.(*lang.Binary); ok && lang.IsLogical(b.Op) {
+				y = "{ " + y + "; }"
+			}
+
+			return fmt.Sprintf("%s %s %s", x, v.Op, y), nil
+		case lang.IsComparison(v.Op):
+			return g.genCompare(v)
+		}
+	case *lang.Unary:
+		x, err := g.genTest(v.X)
+		if err != nil {
+			return "", err
+		}
+
+		if b, ok := v.X.(*lang.Binary); ok && lang.IsLogical(b.Op) {
+			x = "{ " + x + "; }"
+		}
+
+		return "! " + x, nil
+	}
+
+	typ, err := g.typeOf(node)
+	if err != nil {
+		return "", err
+	}
+	if !lang.IsBool(typ) {
+		return "", genError(node, "expected a condition")
+	}
+
+	word, err := g.genWord(node)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("[[ %s == true ]]", word), nil
+}
+
+// genCompare returns the bash test for a comparison, the type of the operands picks the test
+func (g *generator) genCompare(b *lang.Binary) (string, error) {
+	xType, err := g.typeOf(b.X)
+	if err != nil {
+		return "", err
+	}
+
+	if lang.IsInt(xType) {
+		x, err := g.genArith(b.X)
+		if err != nil {
+			return "", err
+		}
+		y, err := g.genArith(b.Y)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("(( %s %s %s ))", x, b.Op, y), nil
+	}
+
+	if b.Op == "<=" || b.Op == ">=" || (lang.IsBool(xType) && b.Op != "==" && b.Op != "!=") {
+		return "", genError(b, "the "+b.Op+" operator can not compare these values")
+	}
+
+	x, err := g.genWord(b.X)
+	if err != nil {
+		return "", err
+	}
+	y, err := g.genWord(b.Y)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("[[ %s %s %s ]]", x, b.Op, y), nil
+}
+
+// genBoolWord returns a single bash word that holds true or false for a condition
+func (g *generator) genBoolWord(node lang.Node) (string, error) {
+	test, err := g.genTest(node)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(`"$( %s && echo true || echo false )"`, test), nil
+}
@@ -0,0 +1,509 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// funcCtx holds the state of the function currently being generated
+type funcCtx struct {
+	name string
+	typ  *lang.FuncType
+	main bool
+
+	// recv is the struct type of the receiver for methods
+	recv lang.Node
+
+	// captures are the variables a function literal captures, they are passed before the parameters
+	captures []string
+
+	// owner is the function that declares the captured variables
+	owner string
+
+	// frame is true if a function literal captures the variables of the function,
+	// every call then gets a number so the literal can tell if the call that created it is still running
+	frame bool
+
+	// self is the name a function declared inside of another function is called by
+	// the closure rebuilds its own fn value under this name so it can call itself
+	self string
+
+	// refs are the variables that are bash name references to a variable owned by the caller
+	refs map[string]bool
+
+	// unread is the list of pipe inputs that have not been read from stdin yet
+	unread []string
+}
+
+// frameVar returns the name of the variable that numbers the calls of a function
+func frameVar(name string) string {
+	return "_bk_frame_" + name
+}
+
+// retVar returns the name of the variable a function stores its return value in
+func retVar(name string) string {
+	return name + "_ret"
+}
+
+// recvVar returns the name of the variable a method stores its receiver in when it returns
+func recvVar(name string) string {
+	return name + "_me"
+}
+
+// genFunc writes a bash function, arguments are passed positionally and
+// return values are stored in global name_ret variables
+// methods take the name of the receiver as the first argument
+func (g *generator) genFunc(name string, fn *lang.Func, recv lang.Node) error {
+	return g.genFuncWith(name, fn, recv, nil, "")
+}
+
+// genFuncWith writes a bash function that takes the values of the captured variables before its arguments
+// self is the name the function can call itself by, it is empty for function literals
+func (g *generator) genFuncWith(name string, fn *lang.Func, recv lang.Node, captures []string, self string) error {
+	parent := g.fn
+	g.fn = newFuncCtx(name, fn.Type)
+	g.fn.recv = recv
+	g.fn.captures = captures
+	g.fn.self = self
+	if len(captures) > 0 {
+		g.fn.owner = parent.name
+	}
+	g.pushScope()
+
+	// the body is written on its own so the call can be numbered once it is known that a literal captures its variables
+	outer := g.buf
+	g.buf = &strings.Builder{}
+	defer func() {
+		g.fn = parent
+		g.buf = outer
+		g.popScope()
+	}()
+
+	g.depth++
+	g.bindParams(fn.Type)
+
+	if err := g.genBlock(fn.Body); err != nil {
+		return err
+	}
+
+	if recv != nil && !endsWithReturn(fn.Body) {
+		g.storeRecv()
+	}
+
+	body := g.buf.String()
+	g.buf = outer
+	g.depth--
+
+	g.line("function %s () {", name)
+	g.depth++
+	if g.fn.frame {
+		g.line("local %s=$(( ++_bk_frames ))", frameVar(name))
+	}
+	g.buf.WriteString(body)
+
+	// bash does not allow empty functions
+	if body == "" && !g.fn.frame {
+		g.line(":")
+	}
+
+	g.depth--
+	g.line("}")
+
+	return nil
+}
+
+// genMain writes the body of the main function directly into the script
+// the script arguments are bound to the main parameters
+func (g *generator) genMain(main *lang.Var) error {
+	fn, ok := main.Default.(*lang.Func)
+	if !ok {
+		return genError(main, "main must be a function")
+	}
+
+	g.fn = newFuncCtx(main.Name, fn.Type)
+	g.fn.main = true
+	g.pushScope()
+	defer func() {
+		g.fn = nil
+		g.popScope()
+	}()
+
+	g.bindParams(fn.Type)
+
+	return g.genBlock(fn.Body)
+}
+
+// newFuncCtx creates the context for generating a function with the given signature
+func newFuncCtx(name string, typ *lang.FuncType) *funcCtx {
+	ctx := &funcCtx{name: name, typ: typ, refs: make(map[string]bool)}
+	for _, input := range typ.Inputs {
+		ctx.unread = append(ctx.unread, input.Name)
+	}
+
+	return ctx
+}
+
+// endsWithReturn returns true if the last statement of the block is a return
+func endsWithReturn(block *lang.Block) bool {
+	if len(block.Stmts) == 0 {
+		return false
+	}
+
+	_, ok := block.Stmts[len(block.Stmts)-1].(*lang.Return)
+	return ok
+}
+
+// bindParams declares the function parameters and copies them out of the positional arguments
+// the receiver of a method is copied into me before anything else is declared, bash resolves names
+// when they are used so a reference to the caller's variable would find any local with the same name
+// arrays, cmds and fn values are passed by name so they are copied by indirectly expanding the named array
+// structs and nested arrays are passed by name and copied key by key, main binds them with a name reference instead
+// the array parameter of main collects all of the script arguments
+// the parameters of functions other than main are local so recursive calls do not overwrite them
+func (g *generator) bindParams(typ *lang.FuncType) {
+	offset := 1
+	if g.fn.recv != nil {
+		g.scope.declare("me", g.fn.recv)
+		g.line(`local -n _bk_arg_1="${1}"`)
+		g.line("local -A me=()")
+		g.copyKeys("me", "", "_bk_arg_1", "")
+		offset++
+	}
+
+	if len(g.fn.captures) > 0 {
+		// the captured variables are only copied once the call of the owner that created the fn value has returned,
+		// until then they are used by name so the literal sees and changes the variables of the running owner
+		frame := frameVar(g.fn.owner)
+		g.line(`local _bk_owner="${%d}"`, offset)
+		g.line(`if [[ "${%s}" != "${_bk_owner}" ]]; then`, frame)
+		g.depth++
+		offset++
+
+		// literals called from here on can not reach the variables of the owner either
+		g.line("local %s=", frame)
+		for _, name := range g.fn.captures {
+			// the captured variable is still declared in the enclosing scope
+			captured, _ := g.scope.Lookup(name)
+			g.scope.declare(name, captured)
+			if isList(captured) {
+				g.line(`eval "local -a %s=( ${%d} )"`, name, offset)
+			} else {
+				g.line(`local %s="${%d}"`, name, offset)
+			}
+			offset++
+		}
+
+		g.depth--
+		g.line("fi")
+	}
+
+	if g.fn.self != "" {
+		g.scope.declare(g.fn.self, typ)
+		g.line("%s%s=%s", g.local("-a"), g.fn.self, g.fnValue(g.fn.name, `"${_bk_owner}"`, g.fn.captures))
+	}
+
+	for i, param := range typ.Params {
+		g.scope.declare(param.Name, param.Type)
+
+		switch {
+		case g.fn.main && lang.IsArray(param.Type):
+			g.line(`%s=( "$@" )`, param.Name)
+		case g.fn.main && (isNested(param.Type) || g.isStruct(param.Type)):
+			g.fn.refs[param.Name] = true
+			g.line(`declare -n %s="${%d}"`, param.Name, i+offset)
+		case isNested(param.Type) || g.isStruct(param.Type):
+			// the argument is only referenced long enough to copy it, a name reference that outlives
+			// the copy would resolve to this function's own variables once a recursive call declares them
+			arg := fmt.Sprintf("_bk_arg_%d", i+offset)
+			g.line(`local -n %s="${%d}"`, arg, i+offset)
+			g.line("local -A %s=()", param.Name)
+			g.copyKeys(param.Name, "", arg, "")
+		case isList(param.Type):
+			g.line(`_bk_ref="${%d}[@]"`, i+offset)
+			g.line(`%s%s=( "${!_bk_ref}" )`, g.local("-a"), param.Name)
+		default:
+			g.line(`%s%s="${%d}"`, g.local(""), param.Name, i+offset)
+		}
+	}
+
+	var inputs []string
+	for _, input := range typ.Inputs {
+		g.scope.declare(input.Name, &lang.TypeName{Name: "string"})
+		inputs = append(inputs, input.Name)
+	}
+	if local := g.local(""); local != "" && len(inputs) > 0 {
+		g.line("%s%s", local, strings.Join(inputs, " "))
+	}
+}
+
+// readInputs writes a read for each pipe input the statement uses that has not been read yet
+// inputs are read in the order they are declared so each one gets the next line of stdin
+func (g *generator) readInputs(node lang.Node) {
+	if g.fn == nil || len(g.fn.unread) == 0 {
+		return
+	}
+
+	last := -1
+	for i, name := range g.fn.unread {
+		if uses(node, name) {
+			last = i
+		}
+	}
+
+	for _, name := range g.fn.unread[:last+1] {
+		g.line("IFS= read -r %s", name)
+	}
+	g.fn.unread = g.fn.unread[last+1:]
+}
+
+// uses returns true if the node references the identifier outside of any nested function
+func uses(node lang.Node, name string) bool {
+	switch v := node.(type) {
+	case nil:
+		return false
+	case *lang.Func:
+		return false
+	case *lang.Ident:
+		return v.Name == name
+	case *lang.Field:
+		return uses(v.X, name)
+	case *lang.KeyValue:
+		return uses(v.Value, name)
+	}
+
+	for _, child := range node.Children() {
+		if uses(child, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// genReturn stores the returned values in the return variables of the current function
+func (g *generator) genReturn(ret *lang.Return) error {
+	if g.fn == nil {
+		return genError(ret, "return outside of a function")
+	}
+
+	if g.fn.main {
+		g.line("exit 0")
+		return nil
+	}
+
+	if len(ret.Values) != len(g.fn.typ.Returns) {
+		return genError(ret, fmt.Sprintf("%s returns %d values but %d were given", g.fn.name, len(g.fn.typ.Returns), len(ret.Values)))
+	}
+
+	for i, value := range ret.Values {
+		name := retVar(g.fn.name)
+		if len(ret.Values) > 1 {
+			name = fmt.Sprintf("%s_%d", name, i)
+		}
+
+		if st, ok := g.scope.StructType(g.fn.typ.Returns[i]); ok {
+			if err := g.setStruct("declare -gA "+name+"=", name, "", st, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isNested(g.fn.typ.Returns[i]) {
+			if err := g.setNested("declare -gA "+name+"=", name, g.fn.typ.Returns[i].(*lang.ArrayType), value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		v, err := g.genValue(value)
+		if err != nil {
+			return err
+		}
+		g.line("%s=%s", name, v)
+	}
+
+	if g.fn.recv != nil {
+		g.storeRecv()
+	}
+	g.line("return")
+	return nil
+}
+
+// storeRecv copies the receiver of the current method into its global receiver variable
+// so the caller can copy the changes back into its own variable
+func (g *generator) storeRecv() {
+	name := recvVar(g.fn.name)
+	g.line("declare -gA %s=()", name)
+	g.copyKeys(name, "", "me", "")
+}
+
+// writeCall writes a call, the receiver of a method call is copied back once the method returns
+func (g *generator) writeCall(call string, ref *funcRef) {
+	g.line("%s", call)
+	if ref.recv == nil {
+		return
+	}
+
+	// genRecv has already checked the receiver is a variable
+	recv := ref.recv.(*lang.Ident).Name
+	g.line("%s=()", recv)
+	g.copyKeys(recv, "", recvVar(ref.name), "")
+}
+
+// funcRef is a function or method that can be called
+type funcRef struct {
+	// name is the name of the bash function
+	name string
+	sig  *lang.FuncType
+
+	// recv is the receiver of a method call, it is nil for functions
+	recv lang.Node
+
+	// value is true if the function is called through a fn value,
+	// name is the bash array that holds the fn value once the call is generated
+	value bool
+}
+
+// lookupFunc finds the function or method that is being called
+func (g *generator) lookupFunc(fn lang.Node) (*funcRef, error) {
+	switch v := fn.(type) {
+	case *lang.Ident:
+		typ, ok := g.scope.Lookup(v.Name)
+		if !ok {
+			return nil, undefined(v)
+		}
+
+		sig, ok := typ.(*lang.FuncType)
+		if !ok {
+			return nil, genError(v, v.SourceName()+" is not a function")
+		}
+
+		return &funcRef{name: v.Name, sig: sig, value: !g.scope.isFunc(v.Name)}, nil
+	case *lang.Field:
+		typ, err := g.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+
+		st, ok := g.scope.StructType(typ)
+		name, named := typ.(*lang.TypeName)
+		if !ok || !named {
+			return nil, genError(v, "only named struct types have methods")
+		}
+
+		method, ok := st.Method(v.Name.Name)
+		if !ok {
+			return nil, genError(v.Name, name.SourceName()+" has no method "+v.Name.Name)
+		}
+
+		return &funcRef{name: methodName(name.Name, method.Name), sig: method.Type.(*lang.FuncType), recv: v.X}, nil
+	default:
+		typ, err := g.typeOf(fn)
+		if err != nil {
+			return nil, err
+		}
+
+		sig, ok := typ.(*lang.FuncType)
+		if !ok {
+			return nil, genError(fn, "only functions can be called")
+		}
+
+		return &funcRef{name: "fn", sig: sig, value: true}, nil
+	}
+}
+
+// byName returns true if the variable can be passed as the i'th argument by name
+// the callee binds its parameters in order so a name it has already declared would refer to its own variable,
+// fn values are only known at run time so their arguments are always copied
+func byName(ref *funcRef, i int, name string) bool {
+	if ref.value || (ref.recv != nil && name == "me") {
+		return false
+	}
+
+	for _, param := range ref.sig.Params[:i] {
+		if param.Name == name {
+			return false
+		}
+	}
+
+	return true
+}
+
+// genCall returns the bash command that calls a function along with the function that is called
+// scalar arguments are passed as words, arrays, cmds, fn values and structs are passed by name
+func (g *generator) genCall(call *lang.Call) (string, *funcRef, error) {
+	ref, err := g.lookupFunc(call.Fn)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(call.Args) != len(ref.sig.Params) {
+		return "", nil, genError(call, fmt.Sprintf("%s takes %d arguments but %d were given", ref.name, len(ref.sig.Params), len(call.Args)))
+	}
+
+	words := []string{ref.name}
+	if ref.value {
+		ref.name, err = g.fnVar(call.Fn)
+		if err != nil {
+			return "", nil, err
+		}
+		words = []string{fmt.Sprintf(`"${%s[@]}"`, ref.name)}
+	}
+
+	if ref.recv != nil {
+		recv, err := g.genRecv(ref.recv)
+		if err != nil {
+			return "", nil, err
+		}
+		words = append(words, recv)
+	}
+
+	for i, arg := range call.Args {
+		typ := ref.sig.Params[i].Type
+
+		if st, ok := g.scope.StructType(typ); ok {
+			tmp := g.tmpVar()
+			if err := g.setStruct(g.local("-A")+tmp+"=", tmp, "", st, arg); err != nil {
+				return "", nil, err
+			}
+			words = append(words, tmp)
+			continue
+		}
+
+		if isNested(typ) {
+			tmp := g.tmpVar()
+			if err := g.setNested(g.local("-A")+tmp+"=", tmp, typ.(*lang.ArrayType), arg); err != nil {
+				return "", nil, err
+			}
+			words = append(words, tmp)
+			continue
+		}
+
+		if !lang.IsArray(typ) && !lang.IsCmd(typ) && !lang.IsFn(typ) {
+			word, err := g.genWord(arg)
+			if err != nil {
+				return "", nil, err
+			}
+			words = append(words, word)
+			continue
+		}
+
+		if name, ok := arg.(*lang.Ident); ok && byName(ref, i, name.Name) && !g.scope.isFunc(name.Name) {
+			words = append(words, name.Name)
+			continue
+		}
+
+		value, err := g.genValue(arg)
+		if err != nil {
+			return "", nil, err
+		}
+
+		tmp := g.tmpVar()
+		g.line("%s%s=%s", g.local("-a"), tmp, value)
+		words = append(words, tmp)
+	}
+
+	return strings.Join(words, " "), ref, nil
+}
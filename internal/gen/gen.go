@@ -0,0 +1,293 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// Client is a code generation client that converts a lang.Node tree into a bash script
+type Client struct {
+	shebang string
+	indent  string
+
+	// importExit is the exit code of the script when the first imported command is missing,
+	// each other imported command exits with the next code
+	importExit int
+}
+
+// NewClient creates a new default gen.Client
+func NewClient() *Client {
+	return &Client{
+		shebang:    "#!/bin/bash",
+		indent:     "    ",
+		importExit: 213,
+	}
+}
+
+// Generate converts the root node of a blowK program into a bash script
+func (c *Client) Generate(node lang.Node) ([]byte, error) {
+	root, ok := node.(*lang.Root)
+	if !ok {
+		return nil, invalidNode(node)
+	}
+
+	g := &generator{
+		client:  c,
+		buf:     &strings.Builder{},
+		imports: make(map[string]string),
+		checked: make(map[string]bool),
+		scope:   newScope(nil),
+	}
+
+	g.line(c.shebang)
+	g.line("")
+
+	for _, node := range root.Imports {
+		if err := g.genStmt(node); err != nil {
+			return nil, err
+		}
+	}
+	if len(g.checked) > 0 {
+		g.line("")
+	}
+
+	// struct types are declared first so they can be used anywhere in the script
+	for _, node := range root.Expressions {
+		if v, ok := node.(*lang.Var); ok && v.IsTypeDecl() {
+			g.scope.DeclareType(v.Name, v.Default.(*lang.StructType))
+		}
+	}
+
+	// declare all the top level functions first so they can be called before they are declared
+	for _, node := range root.Expressions {
+		if v, ok := node.(*lang.Var); ok && v.IsFuncDecl() {
+			g.scope.declareFunc(v.Name, v.Default.(*lang.Func).Type)
+		}
+	}
+
+	// global variables are declared before the functions are written so function bodies can use them
+	for _, node := range root.Expressions {
+		if v, ok := node.(*lang.Var); ok && !v.IsFuncDecl() && !v.IsTypeDecl() {
+			typ, err := g.declType(v)
+			if err != nil {
+				return nil, err
+			}
+			g.scope.declare(v.Name, typ)
+		}
+	}
+
+	// functions and methods are written before any other code so they are defined by the time they are called
+	for _, node := range root.Expressions {
+		v, ok := node.(*lang.Var)
+		switch {
+		case ok && v.IsFuncDecl():
+			if err := g.genFunc(v.Name, v.Default.(*lang.Func), nil); err != nil {
+				return nil, err
+			}
+			g.line("")
+		case ok && v.IsTypeDecl():
+			if err := g.genMethods(v.Name, v.Default.(*lang.StructType)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, node := range root.Expressions {
+		if v, ok := node.(*lang.Var); ok && (v.IsFuncDecl() || v.IsTypeDecl()) {
+			continue
+		}
+
+		if err := g.genStmt(node); err != nil {
+			return nil, err
+		}
+	}
+
+	if root.Main != nil {
+		if err := g.genMain(root.Main); err != nil {
+			return nil, err
+		}
+	}
+
+	return []byte(g.buf.String()), nil
+}
+
+// generator holds the state for a single call to Generate
+type generator struct {
+	client *Client
+	buf    *strings.Builder
+	depth  int
+
+	// imports maps the name a command is imported as to the command name
+	imports map[string]string
+
+	// checked are the imported commands that are already checked for at startup
+	checked map[string]bool
+
+	// scope is the inner most scope of declared variables
+	scope *scope
+
+	// fn is the function currently being generated, it is nil at the top level
+	fn *funcCtx
+
+	// tmp is used to create unique temporary variable names
+	tmp int
+
+	// anon is used to create unique names for function literals
+	anon int
+
+	// copyRets is true if the current statement makes more than one call, every return value it uses is copied
+	// before the next call so a recursive call can not overwrite it
+	copyRets bool
+}
+
+// line writes a single line of bash at the current indent depth
+func (g *generator) line(format string, a ...interface{}) {
+	if format == "" {
+		g.buf.WriteString("\n")
+		return
+	}
+
+	g.buf.WriteString(strings.Repeat(g.client.indent, g.depth))
+	g.buf.WriteString(fmt.Sprintf(format, a...))
+	g.buf.WriteString("\n")
+}
+
+// tmpVar returns a new unique temporary variable name
+func (g *generator) tmpVar() string {
+	g.tmp++
+	return fmt.Sprintf("_bk_tmp_%d", g.tmp)
+}
+
+// local returns the start of a declaration of a new variable with the given declare flags
+// variables of functions other than main are local so they can not clobber the variables of other functions,
+// everywhere else only associative arrays and name references need to be declared
+func (g *generator) local(flags string) string {
+	switch {
+	case g.fn != nil && !g.fn.main && flags == "":
+		return "local "
+	case g.fn != nil && !g.fn.main:
+		return "local " + flags + " "
+	case flags == "-A" || flags == "-n":
+		return "declare " + flags + " "
+	default:
+		return ""
+	}
+}
+
+// listFlags returns the declare flags for a variable of the given type that is stored in a bash variable
+func listFlags(typ lang.Node) string {
+	if isList(typ) {
+		return "-a"
+	}
+
+	return ""
+}
+
+// pushScope starts a new inner scope
+// scopes inside of functions other than main are local since their variables do not outlive the call
+func (g *generator) pushScope() {
+	g.scope = newScope(g.scope)
+	g.scope.local = g.fn != nil && !g.fn.main
+}
+
+// popScope returns to the parent scope
+func (g *generator) popScope() {
+	g.scope = g.scope.parent
+}
+
+// scope adds the bash functions and locals of the generated code to a lang.TypeScope
+type scope struct {
+	*lang.TypeScope
+	parent *scope
+
+	// funcs are the names in vars that are declared as bash functions rather than fn values
+	funcs map[string]bool
+
+	// local is true if the scope is inside of a function other than main
+	local bool
+}
+
+// newScope creates a new scope inside of the parent scope
+func newScope(parent *scope) *scope {
+	var types *lang.TypeScope
+	if parent != nil {
+		types = parent.TypeScope
+	}
+
+	return &scope{
+		TypeScope: lang.NewTypeScope(types),
+		funcs:     make(map[string]bool),
+		parent:    parent,
+	}
+}
+
+// declare adds a variable to the scope
+func (s *scope) declare(name string, typ lang.Node) {
+	s.Declare(name, typ)
+	delete(s.funcs, name)
+}
+
+// declareFunc adds a bash function to the scope
+func (s *scope) declareFunc(name string, typ *lang.FuncType) {
+	s.Declare(name, typ)
+	s.funcs[name] = true
+}
+
+// owner returns the scope the name is declared in
+func (s *scope) owner(name string) (*scope, bool) {
+	for ; s != nil; s = s.parent {
+		if _, ok := s.Declared(name); ok {
+			return s, true
+		}
+	}
+
+	return nil, false
+}
+
+// isFunc returns true if the name refers to a bash function rather than a variable
+func (s *scope) isFunc(name string) bool {
+	owner, ok := s.owner(name)
+	return ok && owner.funcs[name]
+}
+
+// quote wraps a string in double quotes, escaping any characters bash would expand
+func quote(s string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		`$`, `\$`,
+		"`", "\\`",
+	)
+
+	return `"` + r.Replace(s) + `"`
+}
+
+// genError returns a code generation error positioned at the node
+func genError(node lang.Node, msg string) error {
+	tok := node.Pos()
+	return errors.New(
+		bear.WithErrType(errors.SyntaxError),
+		bear.WithExitCode(errors.GenFailed),
+		bear.WithLabels(msg),
+		errors.WithCode(errors.GenCode),
+		errors.At(tok.FileName, tok.LineNumber, tok.ColNumber, tok.Value),
+	)
+}
+
+// undefined returns an error for a reference to an undeclared identifier
+func undefined(ident *lang.Ident) error {
+	return genError(ident, "undefined: "+ident.SourceName())
+}
+
+// invalidNode returns an error for a node the generator does not support
+func invalidNode(node lang.Node) error {
+	return errors.New(
+		bear.WithErrType(errors.InvalidNode),
+		bear.WithExitCode(errors.GenFailed),
+		bear.WithTag("node type", fmt.Sprintf("%T", node)),
+	)
+}
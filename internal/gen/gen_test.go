@@ -0,0 +1,995 @@
+package gen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+	"github.com/bjatkin/blow-k/internal/load"
+)
+
+func TestClient_Generate(t *testing.T) {
+	type args struct {
+		node lang.Node
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			"empty script",
+			args{
+				node: &lang.Root{},
+			},
+			"#!/bin/bash\n\n",
+			false,
+		},
+		{
+			"imports",
+			args{
+				node: &lang.Root{
+					Imports: []lang.Node{
+						&lang.Import{Name: "echo", As: "print"},
+						&lang.Import{Name: "grep"},
+						&lang.Import{Name: "echo"},
+					},
+					Expressions: []lang.Node{
+						&lang.Exec{Expr: &lang.Cmd{Name: &lang.Ident{Name: "print"}, Args: []lang.Node{&lang.String{Value: "hi"}}}},
+					},
+				},
+			},
+			"#!/bin/bash\n\n" +
+				"if ! command -v echo > /dev/null; then\n" +
+				"    echo \"imported command echo could not be found\" >&2\n" +
+				"    exit 213\n" +
+				"fi\n" +
+				"if ! command -v grep > /dev/null; then\n" +
+				"    echo \"imported command grep could not be found\" >&2\n" +
+				"    exit 214\n" +
+				"fi\n\n" +
+				"echo \"hi\"\n",
+			false,
+		},
+		{
+			"string variables",
+			args{
+				node: &lang.Root{
+					Expressions: []lang.Node{
+						&lang.Var{Name: "a", Type: &lang.TypeName{Name: "string"}},
+						&lang.Var{Name: "b", Default: &lang.String{Value: `say "$hi"`}},
+						&lang.Var{Name: "c", Default: &lang.Ident{Name: "b"}},
+					},
+				},
+			},
+			"#!/bin/bash\n\n" +
+				"a=\"\"\n" +
+				"b=\"say \\\"\\$hi\\\"\"\n" +
+				"c=\"${b}\"\n",
+			false,
+		},
+		{
+			"string array variables",
+			args{
+				node: &lang.Root{
+					Expressions: []lang.Node{
+						&lang.Var{Name: "a", Type: &lang.ArrayType{Elem: &lang.TypeName{Name: "string"}}},
+						&lang.Var{Name: "b", Default: &lang.Ident{Name: "a"}},
+					},
+				},
+			},
+			"#!/bin/bash\n\n" +
+				"a=()\n" +
+				"b=( \"${a[@]}\" )\n",
+			false,
+		},
+		{
+			"functions",
+			args{
+				node: func() lang.Node {
+					str := &lang.TypeName{Name: "string"}
+					ask := &lang.FuncType{
+						Params:  []*lang.Var{{Name: "q", Type: str}},
+						Inputs:  []*lang.Ident{{Name: "resp"}},
+						Returns: []lang.Node{str},
+					}
+					main := &lang.FuncType{
+						Params: []*lang.Var{{Name: "args", Type: &lang.ArrayType{Elem: str}}},
+					}
+					return &lang.Root{
+						Imports: []lang.Node{&lang.Import{Name: "echo"}},
+						Main: &lang.Var{Name: "main", Type: main, Default: &lang.Func{
+							Type: main,
+							Body: &lang.Block{Stmts: []lang.Node{
+								&lang.Var{Name: "name", Default: &lang.Call{
+									Fn:   &lang.Ident{Name: "ask"},
+									Args: []lang.Node{&lang.String{Value: "name?"}},
+								}},
+								&lang.Return{},
+							}},
+						}},
+						Expressions: []lang.Node{
+							&lang.Var{Name: "ask", Type: ask, Default: &lang.Func{
+								Type: ask,
+								Body: &lang.Block{Stmts: []lang.Node{
+									&lang.Exec{Expr: &lang.Cmd{
+										Name: &lang.Ident{Name: "echo"},
+										Args: []lang.Node{&lang.Ident{Name: "q"}},
+									}},
+									&lang.Return{Values: []lang.Node{&lang.Ident{Name: "resp"}}},
+								}},
+							}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"if ! command -v echo > /dev/null; then\n" +
+				"    echo \"imported command echo could not be found\" >&2\n" +
+				"    exit 213\n" +
+				"fi\n\n" +
+				"function ask () {\n" +
+				"    local q=\"${1}\"\n" +
+				"    local resp\n" +
+				"    echo \"${q}\"\n" +
+				"    IFS= read -r resp\n" +
+				"    ask_ret=\"${resp}\"\n" +
+				"    return\n" +
+				"}\n\n" +
+				"args=( \"$@\" )\n" +
+				"ask \"name?\"\n" +
+				"name=\"${ask_ret}\"\n" +
+				"exit 0\n",
+			false,
+		},
+		{
+			"wrong number of arguments",
+			args{
+				node: &lang.Root{
+					Expressions: []lang.Node{
+						&lang.Var{Name: "f", Default: &lang.Func{
+							Type: &lang.FuncType{Returns: []lang.Node{&lang.TypeName{Name: "string"}}},
+							Body: &lang.Block{},
+						}},
+						&lang.Var{Name: "a", Default: &lang.Call{
+							Fn:   &lang.Ident{Name: "f"},
+							Args: []lang.Node{&lang.String{Value: "x"}},
+						}},
+					},
+				},
+			},
+			"",
+			true,
+		},
+		{
+			"structs",
+			args{
+				node: func() lang.Node {
+					str := &lang.TypeName{Name: "string"}
+					v2 := &lang.StructType{
+						Fields: []*lang.Var{
+							{Name: "x", Type: str, Default: &lang.String{Value: "5"}},
+							{Name: "y", Type: str},
+						},
+					}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "v2", Default: v2},
+							&lang.Var{Name: "square", Default: &lang.StructType{
+								Fields: []*lang.Var{
+									{Name: "one", Type: &lang.TypeName{Name: "v2"}},
+									{Name: "tags", Type: &lang.ArrayType{Elem: str}},
+								},
+							}},
+							&lang.Var{Name: "a", Type: &lang.TypeName{Name: "square"}, Default: &lang.StructLit{
+								Fields: []*lang.KeyValue{
+									{Key: &lang.Ident{Name: "one"}, Value: &lang.StructLit{
+										Fields: []*lang.KeyValue{{Key: &lang.Ident{Name: "y"}, Value: &lang.String{Value: "1"}}},
+									}},
+								},
+							}},
+							&lang.Assign{
+								Target: &lang.Field{X: &lang.Ident{Name: "a"}, Name: &lang.Ident{Name: "one"}},
+								Value:  &lang.StructLit{},
+							},
+							&lang.Var{Name: "b", Default: &lang.Field{
+								X:    &lang.Field{X: &lang.Ident{Name: "a"}, Name: &lang.Ident{Name: "one"}},
+								Name: &lang.Ident{Name: "x"},
+							}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"declare -A a=( [one.x]=\"5\" [one.y]=\"1\" [tags.len]=0 )\n" +
+				"a+=( [one.x]=\"5\" [one.y]=\"\" )\n" +
+				"b=\"${a[one.x]}\"\n",
+			false,
+		},
+		{
+			"unknown struct field",
+			args{
+				node: &lang.Root{
+					Expressions: []lang.Node{
+						&lang.Var{Name: "v2", Default: &lang.StructType{}},
+						&lang.Var{Name: "a", Type: &lang.TypeName{Name: "v2"}, Default: &lang.StructLit{
+							Fields: []*lang.KeyValue{{Key: &lang.Ident{Name: "z"}, Value: &lang.String{Value: "1"}}},
+						}},
+					},
+				},
+			},
+			"",
+			true,
+		},
+		{
+			"loops",
+			args{
+				node: func() lang.Node {
+					i := &lang.Ident{Name: "i"}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Loop{
+								Init: &lang.Var{Name: "i", Default: &lang.Int{Value: "0"}},
+								Cond: &lang.Binary{Op: "<", X: i, Y: &lang.Int{Value: "10"}},
+								Post: &lang.IncDec{Op: "++", X: i},
+								Body: &lang.Block{},
+							},
+							&lang.Var{Name: "s", Default: &lang.String{Value: "a"}},
+							&lang.Loop{
+								Cond: &lang.Binary{Op: "!=", X: &lang.Ident{Name: "s"}, Y: &lang.String{Value: "aa"}},
+								Body: &lang.Block{Stmts: []lang.Node{
+									&lang.Assign{
+										Target: &lang.Ident{Name: "s"},
+										Value:  &lang.Binary{Op: "+", X: &lang.Ident{Name: "s"}, Y: &lang.String{Value: "a"}},
+									},
+								}},
+							},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"for (( i=0; i < 10; i++ )); do\n" +
+				"    :\n" +
+				"done\n" +
+				"s=\"a\"\n" +
+				"while [[ \"${s}\" != \"aa\" ]]; do\n" +
+				"    s=\"${s}\"\"a\"\n" +
+				"done\n",
+			false,
+		},
+		{
+			"loop conditions that call functions",
+			args{
+				node: func() lang.Node {
+					intType := &lang.TypeName{Name: "int"}
+					sq := &lang.FuncType{Params: []*lang.Var{{Name: "n", Type: intType}}, Returns: []lang.Node{intType}}
+					c := &lang.Ident{Name: "c"}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "sq", Type: sq, Default: &lang.Func{
+								Type: sq,
+								Body: &lang.Block{Stmts: []lang.Node{&lang.Return{Values: []lang.Node{
+									&lang.Binary{Op: "*", X: &lang.Ident{Name: "n"}, Y: &lang.Ident{Name: "n"}},
+								}}}},
+							}},
+							&lang.Var{Name: "c", Default: &lang.Int{Value: "0"}},
+							&lang.Loop{
+								Cond: &lang.Binary{
+									Op: "!=",
+									X:  &lang.Binary{Op: "%", X: &lang.Call{Fn: &lang.Ident{Name: "sq"}, Args: []lang.Node{c}}, Y: &lang.Int{Value: "5"}},
+									Y:  &lang.Int{Value: "4"},
+								},
+								Post: &lang.IncDec{Op: "++", X: c},
+								Body: &lang.Block{},
+							},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"function sq () {\n" +
+				"    local n=\"${1}\"\n" +
+				"    sq_ret=\"$(( n * n ))\"\n" +
+				"    return\n" +
+				"}\n\n" +
+				"c=0\n" +
+				"while\n" +
+				"    sq \"${c}\"\n" +
+				"    (( ${sq_ret} % 5 != 4 ))\n" +
+				"do\n" +
+				"    c=$(( c + 1 ))\n" +
+				"done\n",
+			false,
+		},
+		{
+			"conditionals",
+			args{
+				node: func() lang.Node {
+					a, b := &lang.Ident{Name: "a"}, &lang.Ident{Name: "b"}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "a", Default: &lang.Int{Value: "1"}},
+							&lang.Var{Name: "b", Default: &lang.Binary{Op: "==", X: a, Y: &lang.Int{Value: "2"}}},
+							&lang.If{
+								Cond: &lang.Binary{
+									Op: "||",
+									X:  &lang.Binary{Op: ">", X: a, Y: &lang.Int{Value: "17"}},
+									Y:  &lang.Unary{Op: "!", X: b},
+								},
+								Then: &lang.Block{},
+								Else: &lang.If{
+									Cond: &lang.Binary{Op: "!=", X: &lang.String{Value: "x"}, Y: &lang.String{Value: "y"}},
+									Then: &lang.Block{},
+									Else: &lang.Block{},
+								},
+							},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"a=1\n" +
+				"b=\"$( (( a == 2 )) && echo true || echo false )\"\n" +
+				"if (( a > 17 )) || ! [[ \"${b}\" == true ]]; then\n" +
+				"    :\n" +
+				"elif [[ \"x\" != \"y\" ]]; then\n" +
+				"    :\n" +
+				"else\n" +
+				"    :\n" +
+				"fi\n",
+			false,
+		},
+		{
+			"else if conditions that call functions",
+			args{
+				node: func() lang.Node {
+					intType := &lang.TypeName{Name: "int"}
+					f := &lang.FuncType{Params: []*lang.Var{{Name: "n", Type: intType}}, Returns: []lang.Node{intType}}
+					x := &lang.Ident{Name: "x"}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "f", Type: f, Default: &lang.Func{
+								Type: f,
+								Body: &lang.Block{Stmts: []lang.Node{&lang.Return{Values: []lang.Node{&lang.Ident{Name: "n"}}}}},
+							}},
+							&lang.Var{Name: "x", Default: &lang.Int{Value: "4"}},
+							&lang.If{
+								Cond: &lang.Binary{Op: ">", X: x, Y: &lang.Int{Value: "5"}},
+								Then: &lang.Block{},
+								Else: &lang.If{
+									Cond: &lang.Binary{
+										Op: "==",
+										X:  &lang.Binary{Op: "%", X: &lang.Call{Fn: &lang.Ident{Name: "f"}, Args: []lang.Node{x}}, Y: &lang.Int{Value: "2"}},
+										Y:  &lang.Int{Value: "0"},
+									},
+									Then: &lang.Block{},
+								},
+							},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"function f () {\n" +
+				"    local n=\"${1}\"\n" +
+				"    f_ret=\"${n}\"\n" +
+				"    return\n" +
+				"}\n\n" +
+				"x=4\n" +
+				"if (( x > 5 )); then\n" +
+				"    :\n" +
+				"elif\n" +
+				"    f \"${x}\"\n" +
+				"    (( ${f_ret} % 2 == 0 ))\n" +
+				"then\n" +
+				"    :\n" +
+				"fi\n",
+			false,
+		},
+		{
+			"condition is not a bool",
+			args{
+				node: &lang.Root{
+					Expressions: []lang.Node{
+						&lang.If{Cond: &lang.String{Value: "a"}, Then: &lang.Block{}},
+					},
+				},
+			},
+			"",
+			true,
+		},
+		{
+			"arrays",
+			args{
+				node: func() lang.Node {
+					a := &lang.Ident{Name: "a"}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{
+								Name:    "a",
+								Type:    &lang.ArrayType{Elem: &lang.TypeName{Name: "int"}},
+								Default: &lang.ArrayLit{},
+							},
+							&lang.Append{X: a, Value: &lang.Int{Value: "1"}},
+							&lang.Append{X: a, Value: &lang.ArrayLit{Elems: []lang.Node{&lang.Int{Value: "2"}, &lang.Int{Value: "3"}}}},
+							&lang.Var{Name: "b", Default: &lang.Slice{X: a, Low: &lang.Int{Value: "1"}}},
+							&lang.Var{Name: "c", Default: &lang.Pop{X: a}},
+							&lang.Assign{
+								Target: &lang.Index{X: a, Index: &lang.Int{Value: "0"}},
+								Value:  &lang.Binary{Op: "+", X: &lang.Index{X: a, Index: &lang.Int{Value: "0"}}, Y: &lang.Ident{Name: "c"}},
+							},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"a=()\n" +
+				"a+=( 1 )\n" +
+				"a+=( 2 3 )\n" +
+				"b=( \"${a[@]:1}\" )\n" +
+				"_bk_last=$(( ${#a[@]} - 1 ))\n" +
+				"_bk_tmp_1=\"${a[_bk_last]}\"\n" +
+				"a=( \"${a[@]:0:_bk_last}\" )\n" +
+				"c=\"${_bk_tmp_1}\"\n" +
+				"a[0]=\"$(( a[0] + c ))\"\n",
+			false,
+		},
+		{
+			"nested arrays",
+			args{
+				node: func() lang.Node {
+					d := &lang.Ident{Name: "d"}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{
+								Name: "d",
+								Type: &lang.ArrayType{Elem: &lang.ArrayType{Elem: &lang.TypeName{Name: "string"}}},
+								Default: &lang.ArrayLit{Elems: []lang.Node{
+									&lang.ArrayLit{Elems: []lang.Node{&lang.String{Value: "hi"}, &lang.String{Value: "world"}}},
+									&lang.ArrayLit{Elems: []lang.Node{&lang.String{Value: "hello"}}},
+								}},
+							},
+							&lang.Append{X: &lang.Index{X: d, Index: &lang.Int{Value: "1"}}, Value: &lang.String{Value: "there"}},
+							&lang.Var{Name: "a", Default: &lang.Index{X: &lang.Index{X: d, Index: &lang.Int{Value: "0"}}, Index: &lang.Int{Value: "1"}}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"declare -A d=( [len]=2 [0,len]=2 [0,0]=\"hi\" [0,1]=\"world\" [1,len]=1 [1,0]=\"hello\" )\n" +
+				"d[1,${d[1,len]}]=\"there\"\n" +
+				"d[1,len]=$(( ${d[1,len]} + 1 ))\n" +
+				"a=\"${d[0,1]}\"\n",
+			false,
+		},
+		{
+			"cmds",
+			args{
+				node: func() lang.Node {
+					c := &lang.Ident{Name: "c"}
+					return &lang.Root{
+						Imports: []lang.Node{&lang.Import{Name: "echo"}},
+						Expressions: []lang.Node{
+							&lang.Var{Name: "c", Default: &lang.Index{X: &lang.Ident{Name: "echo"}, Index: &lang.String{Value: "hello"}}},
+							&lang.Append{X: c, Value: &lang.String{Value: "world"}},
+							&lang.Assign{Target: &lang.Index{X: c, Index: &lang.Int{Value: "0"}}, Value: &lang.String{Value: "hi"}},
+							&lang.Var{Name: "d", Default: &lang.Slice{X: c, Low: &lang.Int{Value: "1"}}},
+							&lang.Exec{Expr: &lang.Cmd{Name: c, Args: []lang.Node{&lang.String{Value: "there"}}}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"if ! command -v echo > /dev/null; then\n" +
+				"    echo \"imported command echo could not be found\" >&2\n" +
+				"    exit 213\n" +
+				"fi\n\n" +
+				"c=( echo \"hello\" )\n" +
+				"c+=( \"world\" )\n" +
+				"c[1]=\"hi\"\n" +
+				"d=( \"${c[0]}\" \"${c[@]:2}\" )\n" +
+				"\"${c[@]}\" \"there\"\n",
+			false,
+		},
+		{
+			"pipelines",
+			args{
+				node: func() lang.Node {
+					read := &lang.FuncType{Inputs: []*lang.Ident{{Name: "in"}}}
+					echo := func(arg lang.Node) lang.Node {
+						return &lang.Exec{Expr: &lang.Cmd{Name: &lang.Ident{Name: "echo"}, Args: []lang.Node{arg}}}
+					}
+					return &lang.Root{
+						Imports: []lang.Node{&lang.Import{Name: "echo"}},
+						Expressions: []lang.Node{
+							&lang.Var{Name: "f", Type: read, Default: &lang.Func{
+								Type: read,
+								Body: &lang.Block{Stmts: []lang.Node{echo(&lang.Ident{Name: "in"})}},
+							}},
+							&lang.Pipeline{Stages: []lang.Node{
+								echo(&lang.String{Value: "hi"}),
+								&lang.Call{Fn: &lang.Ident{Name: "f"}},
+							}},
+							&lang.Pipeline{Stages: []lang.Node{
+								echo(&lang.String{Value: "100%"}),
+								&lang.Call{Fn: &lang.Ident{Name: "f"}},
+							}},
+							&lang.Var{Name: "a", Default: &lang.Pipeline{Stages: []lang.Node{
+								echo(&lang.String{Value: "hi"}),
+								&lang.Call{Fn: &lang.Ident{Name: "f"}},
+							}}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"if ! command -v echo > /dev/null; then\n" +
+				"    echo \"imported command echo could not be found\" >&2\n" +
+				"    exit 213\n" +
+				"fi\n\n" +
+				"function f () {\n" +
+				"    local in\n" +
+				"    IFS= read -r in\n" +
+				"    echo \"${in}\"\n" +
+				"}\n\n" +
+				"echo \"hi\" | f\n" +
+				"echo \"100%\" | f\n" +
+				"a=\"$( echo \"hi\" | f )\"\n",
+			false,
+		},
+		{
+			"closures",
+			args{
+				node: func() lang.Node {
+					intType := &lang.TypeName{Name: "int"}
+					inc := &lang.FuncType{Params: []*lang.Var{{Name: "x", Type: intType}}, Returns: []lang.Node{intType}}
+					adder := &lang.FuncType{Params: []*lang.Var{{Name: "n", Type: intType}}, Returns: []lang.Node{inc}}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "adder", Type: adder, Default: &lang.Func{
+								Type: adder,
+								Body: &lang.Block{Stmts: []lang.Node{
+									&lang.Return{Values: []lang.Node{&lang.Func{
+										Type: inc,
+										Body: &lang.Block{Stmts: []lang.Node{
+											&lang.Return{Values: []lang.Node{&lang.Binary{Op: "+", X: &lang.Ident{Name: "x"}, Y: &lang.Ident{Name: "n"}}}},
+										}},
+									}}},
+								}},
+							}},
+							&lang.Var{Name: "f", Default: &lang.Call{Fn: &lang.Ident{Name: "adder"}, Args: []lang.Node{&lang.Int{Value: "2"}}}},
+							&lang.Var{Name: "a", Default: &lang.Call{Fn: &lang.Ident{Name: "f"}, Args: []lang.Node{&lang.Int{Value: "1"}}}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"function adder () {\n" +
+				"    local _bk_frame_adder=$(( ++_bk_frames ))\n" +
+				"    local n=\"${1}\"\n" +
+				"    function anon_1 () {\n" +
+				"        local _bk_owner=\"${1}\"\n" +
+				"        if [[ \"${_bk_frame_adder}\" != \"${_bk_owner}\" ]]; then\n" +
+				"            local _bk_frame_adder=\n" +
+				"            local n=\"${2}\"\n" +
+				"        fi\n" +
+				"        local x=\"${3}\"\n" +
+				"        anon_1_ret=\"$(( x + n ))\"\n" +
+				"        return\n" +
+				"    }\n" +
+				"    adder_ret=( anon_1 \"${_bk_frame_adder}\" \"${n}\" )\n" +
+				"    return\n" +
+				"}\n\n" +
+				"adder 2\n" +
+				"f=( \"${adder_ret[@]}\" )\n" +
+				"\"${f[@]}\" 1\n" +
+				"_bk_ref=\"${f[0]}_ret\"\n" +
+				"_bk_tmp_1=\"${!_bk_ref}\"\n" +
+				"a=\"${_bk_tmp_1}\"\n",
+			false,
+		},
+		{
+			"recursion",
+			args{
+				node: func() lang.Node {
+					intType := &lang.TypeName{Name: "int"}
+					fib := &lang.FuncType{Params: []*lang.Var{{Name: "n", Type: intType}}, Returns: []lang.Node{intType}}
+					call := func(d string) lang.Node {
+						return &lang.Call{Fn: &lang.Ident{Name: "fib"}, Args: []lang.Node{&lang.Binary{Op: "-", X: &lang.Ident{Name: "n"}, Y: &lang.Int{Value: d}}}}
+					}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "fib", Type: fib, Default: &lang.Func{
+								Type: fib,
+								Body: &lang.Block{Stmts: []lang.Node{
+									&lang.If{
+										Cond: &lang.Binary{Op: "<", X: &lang.Ident{Name: "n"}, Y: &lang.Int{Value: "2"}},
+										Then: &lang.Block{Stmts: []lang.Node{&lang.Return{Values: []lang.Node{&lang.Ident{Name: "n"}}}}},
+									},
+									&lang.Return{Values: []lang.Node{&lang.Binary{Op: "+", X: call("1"), Y: call("2")}}},
+								}},
+							}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"function fib () {\n" +
+				"    local n=\"${1}\"\n" +
+				"    if (( n < 2 )); then\n" +
+				"        fib_ret=\"${n}\"\n" +
+				"        return\n" +
+				"    fi\n" +
+				"    fib \"$(( n - 1 ))\"\n" +
+				"    local _bk_tmp_1=\"${fib_ret}\"\n" +
+				"    fib \"$(( n - 2 ))\"\n" +
+				"    local _bk_tmp_2=\"${fib_ret}\"\n" +
+				"    fib_ret=\"$(( ${_bk_tmp_1} + ${_bk_tmp_2} ))\"\n" +
+				"    return\n" +
+				"}\n\n",
+			false,
+		},
+		{
+			"nested functions with the same name",
+			args{
+				node: func() lang.Node {
+					empty := &lang.FuncType{}
+					helper := func(msg string) lang.Node {
+						return &lang.Var{Name: "helper", Default: &lang.Func{
+							Type: empty,
+							Body: &lang.Block{Stmts: []lang.Node{
+								&lang.Exec{Expr: &lang.Cmd{Name: &lang.Ident{Name: "echo"}, Args: []lang.Node{&lang.String{Value: msg}}}},
+							}},
+						}}
+					}
+					call := func(name string) lang.Node {
+						return &lang.Call{Fn: &lang.Ident{Name: name}}
+					}
+					return &lang.Root{
+						Imports: []lang.Node{&lang.Import{Name: "echo"}},
+						Expressions: []lang.Node{
+							&lang.Var{Name: "f2", Type: empty, Default: &lang.Func{
+								Type: empty,
+								Body: &lang.Block{Stmts: []lang.Node{helper("f2 helper"), call("helper")}},
+							}},
+							&lang.Var{Name: "f1", Type: empty, Default: &lang.Func{
+								Type: empty,
+								Body: &lang.Block{Stmts: []lang.Node{helper("f1 helper"), call("f2"), call("helper")}},
+							}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"if ! command -v echo > /dev/null; then\n" +
+				"    echo \"imported command echo could not be found\" >&2\n" +
+				"    exit 213\n" +
+				"fi\n\n" +
+				"function f2 () {\n" +
+				"    function anon_1 () {\n" +
+				"        echo \"f2 helper\"\n" +
+				"    }\n" +
+				"    local -a helper=( anon_1 )\n" +
+				"    \"${helper[@]}\"\n" +
+				"}\n\n" +
+				"function f1 () {\n" +
+				"    function anon_2 () {\n" +
+				"        echo \"f1 helper\"\n" +
+				"    }\n" +
+				"    local -a helper=( anon_2 )\n" +
+				"    f2\n" +
+				"    \"${helper[@]}\"\n" +
+				"}\n\n",
+			false,
+		},
+		{
+			"nested functions capture variables",
+			args{
+				node: func() lang.Node {
+					intType := &lang.TypeName{Name: "int"}
+					get := &lang.FuncType{Returns: []lang.Node{intType}}
+					mk := &lang.FuncType{Params: []*lang.Var{{Name: "n", Type: intType}}, Returns: []lang.Node{get}}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "mk", Type: mk, Default: &lang.Func{
+								Type: mk,
+								Body: &lang.Block{Stmts: []lang.Node{
+									&lang.Var{Name: "helper", Default: &lang.Func{
+										Type: get,
+										Body: &lang.Block{Stmts: []lang.Node{&lang.Return{Values: []lang.Node{&lang.Ident{Name: "n"}}}}},
+									}},
+									&lang.Return{Values: []lang.Node{&lang.Ident{Name: "helper"}}},
+								}},
+							}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"function mk () {\n" +
+				"    local _bk_frame_mk=$(( ++_bk_frames ))\n" +
+				"    local n=\"${1}\"\n" +
+				"    function anon_1 () {\n" +
+				"        local _bk_owner=\"${1}\"\n" +
+				"        if [[ \"${_bk_frame_mk}\" != \"${_bk_owner}\" ]]; then\n" +
+				"            local _bk_frame_mk=\n" +
+				"            local n=\"${2}\"\n" +
+				"        fi\n" +
+				"        anon_1_ret=\"${n}\"\n" +
+				"        return\n" +
+				"    }\n" +
+				"    local -a helper=( anon_1 \"${_bk_frame_mk}\" \"${n}\" )\n" +
+				"    mk_ret=( \"${helper[@]}\" )\n" +
+				"    return\n" +
+				"}\n\n",
+			false,
+		},
+		{
+			"recursive nested functions",
+			args{
+				node: func() lang.Node {
+					intType := &lang.TypeName{Name: "int"}
+					count := &lang.FuncType{Params: []*lang.Var{{Name: "n", Type: intType}}, Returns: []lang.Node{intType}}
+					outer := &lang.FuncType{Params: []*lang.Var{{Name: "base", Type: intType}}}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "outer", Type: outer, Default: &lang.Func{
+								Type: outer,
+								Body: &lang.Block{Stmts: []lang.Node{
+									&lang.Var{Name: "count", Default: &lang.Func{
+										Type: count,
+										Body: &lang.Block{Stmts: []lang.Node{
+											&lang.If{
+												Cond: &lang.Binary{Op: "<", X: &lang.Ident{Name: "n"}, Y: &lang.Int{Value: "1"}},
+												Then: &lang.Block{Stmts: []lang.Node{&lang.Return{Values: []lang.Node{&lang.Ident{Name: "base"}}}}},
+											},
+											&lang.Return{Values: []lang.Node{&lang.Call{
+												Fn:   &lang.Ident{Name: "count"},
+												Args: []lang.Node{&lang.Binary{Op: "-", X: &lang.Ident{Name: "n"}, Y: &lang.Int{Value: "1"}}},
+											}}},
+										}},
+									}},
+								}},
+							}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"function outer () {\n" +
+				"    local _bk_frame_outer=$(( ++_bk_frames ))\n" +
+				"    local base=\"${1}\"\n" +
+				"    function anon_1 () {\n" +
+				"        local _bk_owner=\"${1}\"\n" +
+				"        if [[ \"${_bk_frame_outer}\" != \"${_bk_owner}\" ]]; then\n" +
+				"            local _bk_frame_outer=\n" +
+				"            local base=\"${2}\"\n" +
+				"        fi\n" +
+				"        local -a count=( anon_1 \"${_bk_owner}\" \"${base}\" )\n" +
+				"        local n=\"${3}\"\n" +
+				"        if (( n < 1 )); then\n" +
+				"            anon_1_ret=\"${base}\"\n" +
+				"            return\n" +
+				"        fi\n" +
+				"        \"${count[@]}\" \"$(( n - 1 ))\"\n" +
+				"        _bk_ref=\"${count[0]}_ret\"\n" +
+				"        local _bk_tmp_1=\"${!_bk_ref}\"\n" +
+				"        anon_1_ret=\"${_bk_tmp_1}\"\n" +
+				"        return\n" +
+				"    }\n" +
+				"    local -a count=( anon_1 \"${_bk_frame_outer}\" \"${base}\" )\n" +
+				"}\n\n",
+			false,
+		},
+		{
+			"percent signs",
+			args{
+				node: func() lang.Node {
+					intType := &lang.TypeName{Name: "int"}
+					f := &lang.FuncType{Params: []*lang.Var{{Name: "s", Type: &lang.TypeName{Name: "string"}}}, Returns: []lang.Node{intType, intType}}
+					mod := &lang.Binary{Op: "%", X: &lang.Int{Value: "7"}, Y: &lang.Int{Value: "3"}}
+					return &lang.Root{
+						Imports: []lang.Node{&lang.Import{Name: "printf"}},
+						Expressions: []lang.Node{
+							&lang.Var{Name: "f", Type: f, Default: &lang.Func{
+								Type: f,
+								Body: &lang.Block{Stmts: []lang.Node{&lang.Return{Values: []lang.Node{mod, &lang.Int{Value: "1"}}}}},
+							}},
+							&lang.Exec{Expr: &lang.Cmd{Name: &lang.Ident{Name: "printf"}, Args: []lang.Node{&lang.String{Value: "%d%%"}, mod}}},
+							&lang.Call{Fn: &lang.Ident{Name: "f"}, Args: []lang.Node{&lang.String{Value: "%s"}}},
+							&lang.Destructure{
+								Names: []*lang.Ident{{Name: "a"}, {Name: "b"}},
+								Value: &lang.Call{Fn: &lang.Ident{Name: "f"}, Args: []lang.Node{&lang.String{Value: "100%"}}},
+							},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"if ! command -v printf > /dev/null; then\n" +
+				"    echo \"imported command printf could not be found\" >&2\n" +
+				"    exit 213\n" +
+				"fi\n\n" +
+				"function f () {\n" +
+				"    local s=\"${1}\"\n" +
+				"    f_ret_0=\"$(( 7 % 3 ))\"\n" +
+				"    f_ret_1=1\n" +
+				"    return\n" +
+				"}\n\n" +
+				"printf \"%d%%\" \"$(( 7 % 3 ))\"\n" +
+				"f \"%s\"\n" +
+				"f \"100%\"\n" +
+				"a=\"${f_ret_0}\"\n" +
+				"b=\"${f_ret_1}\"\n",
+			false,
+		},
+		{
+			"call values with percent signs",
+			args{
+				node: func() lang.Node {
+					strType := &lang.TypeName{Name: "string"}
+					f := &lang.FuncType{Params: []*lang.Var{{Name: "s", Type: strType}}, Returns: []lang.Node{strType}}
+					return &lang.Root{
+						Expressions: []lang.Node{
+							&lang.Var{Name: "f", Type: f, Default: &lang.Func{
+								Type: f,
+								Body: &lang.Block{Stmts: []lang.Node{&lang.Return{Values: []lang.Node{&lang.Ident{Name: "s"}}}}},
+							}},
+							&lang.Var{Name: "a", Default: &lang.Call{Fn: &lang.Ident{Name: "f"}, Args: []lang.Node{&lang.String{Value: "100%"}}}},
+						},
+					}
+				}(),
+			},
+			"#!/bin/bash\n\n" +
+				"function f () {\n" +
+				"    local s=\"${1}\"\n" +
+				"    f_ret=\"${s}\"\n" +
+				"    return\n" +
+				"}\n\n" +
+				"f \"100%\"\n" +
+				"a=\"${f_ret}\"\n",
+			false,
+		},
+		{
+			"mixed array literal",
+			args{
+				node: &lang.Root{
+					Expressions: []lang.Node{
+						&lang.Var{Name: "a", Default: &lang.ArrayLit{Elems: []lang.Node{&lang.Int{Value: "1"}, &lang.String{Value: "2"}}}},
+					},
+				},
+			},
+			"",
+			true,
+		},
+		{
+			"undefined variable",
+			args{
+				node: &lang.Root{
+					Expressions: []lang.Node{
+						&lang.Var{Name: "a", Default: &lang.Ident{Name: "b"}},
+					},
+				},
+			},
+			"",
+			true,
+		},
+		{
+			"not a root node",
+			args{
+				node: &lang.Import{Name: "echo"},
+			},
+			"",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewClient().Generate(tt.args.node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Generate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Generate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// runSrc loads the source file, generates its script and runs it with bash, the output of the script is returned
+func runSrc(t *testing.T, src string) string {
+	path := filepath.Join(t.TempDir(), "main.bk")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatalf("runSrc() failed to write src file %v", err)
+	}
+
+	root, _, err := load.NewClient().Load(path)
+	if err != nil {
+		t.Fatalf("runSrc() failed to load src file %v", err)
+	}
+
+	script, err := NewClient().Generate(root)
+	if err != nil {
+		t.Fatalf("runSrc() failed to generate script %v", err)
+	}
+
+	run := exec.Command("bash", "-c", string(script))
+	out, err := run.Output()
+	if err != nil {
+		t.Fatalf("runSrc() script failed %v\n%s", err, script)
+	}
+
+	return string(out)
+}
+
+func TestClient_Generate_run(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"shadowed block variables",
+			"import echo\nmain:(): {\n    x :: 1\n    if true {\n        x :: \"inner\"\n        $echo[x]\n    }\n    $echo[x + 1]\n}\n",
+			"inner\n2\n",
+		},
+		{
+			"main variables do not overwrite globals",
+			"import echo\ng :: 1\nshow:(): {\n    $echo[g + 1]\n}\nmain:(): {\n    g :: \"two\"\n    $echo[g]\n    show()\n}\n",
+			"two\n2\n",
+		},
+		{
+			"locals are not seen by called functions",
+			"import echo\ncount :: 5\ng:(): {\n    $echo[count + 1]\n}\nf:(): {\n    count :: \"shadow\"\n    $echo[count]\n    g()\n}\nmain:(): {\n    f()\n}\n",
+			"shadow\n6\n",
+		},
+		{
+			"bash variables",
+			"import echo\nimport expr\nPATH :: \"nope\"\nmain:(): {\n    IFS :: \"x\"\n    $expr[1, \"+\", 1]\n    $echo[PATH, IFS]\n}\n",
+			"2\nnope x\n",
+		},
+		{
+			"nested array lengths",
+			"import echo\nmain:(): {\n    d:[][]string: [[\"a\", \"b\"], [\"c\"], []]\n    loop i :: 0, i < len(d), i++ {\n        loop j :: 0, j < len(d[i]), j++ {\n            $echo[i, j, d[i][j]]\n        }\n    }\n    $echo[len(d[2]), len([1, 2])]\n}\n",
+			"0 0 a\n0 1 b\n1 0 c\n0 2\n",
+		},
+		{
+			"method locals named like the receiver",
+			"import echo\nv2 :: < x, y:int\n    bump:(): {\n        p :: 100\n        me.x : me.x + 1\n        $echo[p + 1]\n    }\n>\nmain:(): {\n    p:v2\n    p.bump()\n    $echo[p.x]\n}\n",
+			"101\n1\n",
+		},
+		{
+			"struct parameters named like the receiver",
+			"import echo\nv2 :: < x, y:int\n    bump:(): {\n        me.x : me.x + 1\n    }\n    add:(p:v2)<int>: {\n        me.x : me.x + p.x\n        me.bump()\n        return me.x\n    }\n>\nmain:(): {\n    p:v2: {x: 2, y: 0}\n    q:v2: {x: 5, y: 0}\n    $echo[p.add(q)]\n    $echo[p.x, q.x]\n}\n",
+			"8\n8 5\n",
+		},
+		{
+			"closures see later changes to captured variables",
+			"import echo\nf:()<string>: {\n    x :: \"a\"\n    g :: ()<string> {\n        return x\n    }\n    x : \"b\"\n    return g()\n}\nmain:(): {\n    $echo[f()]\n}\n",
+			"b\n",
+		},
+		{
+			"closures assign to captured variables",
+			"import echo\nmain:(): {\n    f()\n}\nf:(): {\n    n :: 1\n    bump :: () {\n        n : n + 1\n    }\n    bump()\n    bump()\n    $echo[n]\n}\n",
+			"3\n",
+		},
+		{
+			"returned closures use the values from when they were created",
+			"import echo\nmk:()<()<string>>: {\n    y :: \"first\"\n    h :: ()<string> {\n        y : y + \"!\"\n        return y\n    }\n    y : \"second\"\n    return h\n}\nmain:(): {\n    k :: mk()\n    $echo[k()]\n    $echo[k()]\n}\n",
+			"first!\nfirst!\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runSrc(t, tt.src); got != tt.want {
+				t.Errorf("Generate() output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package gen
+
+import (
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// genIf writes an if statement along with all of its else if and else branches
+func (g *generator) genIf(n *lang.If) error {
+	test, err := g.genTest(n.Cond)
+	if err != nil {
+		return err
+	}
+
+	g.line("if %s; then", test)
+	if err := g.genBranch(n.Then); err != nil {
+		return err
+	}
+
+	for n.Else != nil {
+		elif, ok := n.Else.(*lang.If)
+		if !ok {
+			g.line("else")
+			if err := g.genBranch(n.Else.(*lang.Block)); err != nil {
+				return err
+			}
+			break
+		}
+
+		// anything the condition needs to run first is part of the elif condition list
+		// so it only runs when the earlier branches did not match
+		var test string
+		pre, err := g.capture(func() error {
+			var err error
+			test, err = g.genTest(elif.Cond)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		if pre == "" {
+			g.line("elif %s; then", test)
+		} else {
+			g.line("elif")
+			g.buf.WriteString(pre)
+			g.depth++
+			g.line("%s", test)
+			g.depth--
+			g.line("then")
+		}
+
+		if err := g.genBranch(elif.Then); err != nil {
+			return err
+		}
+		n = elif
+	}
+
+	g.line("fi")
+	return nil
+}
+
+// genBranch writes the body of a single if branch
+func (g *generator) genBranch(body *lang.Block) error {
+	g.depth++
+	start := g.buf.Len()
+
+	if err := g.genBlock(body); err != nil {
+		return err
+	}
+
+	// bash does not allow empty branches
+	if g.buf.Len() == start {
+		g.line(":")
+	}
+
+	g.depth--
+	return nil
+}
@@ -0,0 +1,153 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// genLoop writes a loop, simple counting loops are written as an arithmetic for loop
+// and everything else is written as a while loop with the post statement at the end of the body
+func (g *generator) genLoop(loop *lang.Loop) error {
+	g.pushScope()
+	defer g.popScope()
+
+	if header, ok := g.forHeader(loop); ok {
+		if local := g.local(""); local != "" {
+			g.line("%s%s", local, loop.Init.(*lang.Var).Name)
+		}
+		g.line("for (( %s )); do", header)
+		return g.genLoopBody(loop.Body, nil)
+	}
+
+	if loop.Init != nil {
+		if err := g.genStmt(loop.Init); err != nil {
+			return err
+		}
+	}
+
+	if loop.Cond == nil {
+		g.line("while true; do")
+		return g.genLoopBody(loop.Body, loop.Post)
+	}
+
+	// anything the condition needs to run first is part of the while condition list
+	// so it is run again before every check
+	var test string
+	pre, err := g.capture(func() error {
+		var err error
+		test, err = g.genTest(loop.Cond)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if pre == "" {
+		g.line("while %s; do", test)
+	} else {
+		g.line("while")
+		g.buf.WriteString(pre)
+		g.depth++
+		g.line("%s", test)
+		g.depth--
+		g.line("do")
+	}
+
+	return g.genLoopBody(loop.Body, loop.Post)
+}
+
+// genLoopBody writes the body of a loop followed by the post statement and the closing done
+func (g *generator) genLoopBody(body *lang.Block, post lang.Node) error {
+	g.depth++
+	start := g.buf.Len()
+
+	if err := g.genBlock(body); err != nil {
+		return err
+	}
+
+	if post != nil {
+		if err := g.genStmt(post); err != nil {
+			return err
+		}
+	}
+
+	// bash does not allow empty loop bodies
+	if g.buf.Len() == start {
+		g.line(":")
+	}
+
+	g.depth--
+	g.line("done")
+	return nil
+}
+
+// forHeader returns the header of an arithmetic for loop if all three clauses are simple int expressions
+func (g *generator) forHeader(loop *lang.Loop) (string, bool) {
+	init, ok := loop.Init.(*lang.Var)
+	if !ok || init.Default == nil || !g.isPure(init.Default) {
+		return "", false
+	}
+
+	if typ, err := g.declType(init); err != nil || !lang.IsInt(typ) {
+		return "", false
+	}
+
+	value, err := g.genArith(init.Default)
+	if err != nil {
+		return "", false
+	}
+	g.scope.declare(init.Name, &lang.TypeName{Name: "int"})
+
+	cond, ok := loop.Cond.(*lang.Binary)
+	if !ok || !lang.IsComparison(cond.Op) || !g.isPure(cond.X) || !g.isPure(cond.Y) {
+		return "", false
+	}
+
+	post, ok := loop.Post.(*lang.IncDec)
+	if !ok || !g.isPure(post.X) {
+		return "", false
+	}
+
+	x, err := g.genArith(cond.X)
+	if err != nil {
+		return "", false
+	}
+	y, err := g.genArith(cond.Y)
+	if err != nil {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s=%s; %s %s %s; %s%s", init.Name, value, x, cond.Op, y, post.X.(*lang.Ident).Name, post.Op), true
+}
+
+// isPure returns true if the node is an int expression that can be written without running any other commands
+func (g *generator) isPure(node lang.Node) bool {
+	switch v := node.(type) {
+	case *lang.Int:
+		return true
+	case *lang.Ident:
+		typ, ok := g.scope.Lookup(v.Name)
+		return ok && lang.IsInt(typ)
+	case *lang.Binary:
+		return !lang.IsComparison(v.Op) && g.isPure(v.X) && g.isPure(v.Y)
+	default:
+		return false
+	}
+}
+
+// capture runs fn and returns the lines it wrote, indented one level deeper, without adding them to the script
+func (g *generator) capture(fn func() error) (string, error) {
+	buf := g.buf
+	g.buf = &strings.Builder{}
+	g.depth++
+
+	err := fn()
+	lines := g.buf.String()
+
+	g.depth--
+	g.buf = buf
+
+	return lines, err
+}
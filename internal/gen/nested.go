@@ -0,0 +1,295 @@
+package gen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// bash has no nested arrays so they are stored in associative arrays, every level of nesting adds
+// a comma separated index to the key and stores its length in a len key
+//
+//	declare -A d=( [len]=2 [0,len]=2 [0,0]="hi" [0,1]="world" [1,len]=1 [1,0]="hello" )
+//
+// array fields of structs use the same layout under the field key
+//
+//	declare -A a=( [tags.len]=1 [tags.0]="hi" )
+
+// isNested returns true if the type is an array of arrays
+func isNested(typ lang.Node) bool {
+	arr, ok := typ.(*lang.ArrayType)
+	return ok && lang.IsArray(arr.Elem)
+}
+
+// isNestedVar returns true if the variable holds a nested array
+func (g *generator) isNestedVar(ident *lang.Ident) bool {
+	typ, _ := g.scope.Lookup(ident.Name)
+	return isNested(typ)
+}
+
+// assocArray returns the associative array and key prefix that hold an array value
+// it returns false if the array is stored in a regular bash array instead
+// nested values that are not stored in a variable are copied into a temporary variable
+func (g *generator) assocArray(node lang.Node) (string, string, *lang.ArrayType, bool, error) {
+	typ, err := g.typeOf(node)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+
+	arr, ok := typ.(*lang.ArrayType)
+	if !ok {
+		return "", "", nil, false, nil
+	}
+
+	switch v := node.(type) {
+	case *lang.Field:
+		name, key, _, err := g.fieldRef(v)
+		if err != nil {
+			return "", "", nil, false, err
+		}
+		return name, key + ".", arr, true, nil
+	case *lang.Index:
+		name, prefix, _, ok, err := g.assocArray(v.X)
+		if err != nil || !ok {
+			return "", "", nil, false, err
+		}
+		i, err := g.genArith(v.Index)
+		if err != nil {
+			return "", "", nil, false, err
+		}
+		return name, prefix + assocIndex(i) + ",", arr, true, nil
+	}
+
+	if !isNested(arr) {
+		return "", "", nil, false, nil
+	}
+
+	switch v := node.(type) {
+	case *lang.Ident:
+		return v.Name, "", arr, true, nil
+	case *lang.Call:
+		ret, _, err := g.genCallValue(v)
+		if err != nil {
+			return "", "", nil, false, err
+		}
+		return ret, "", arr, true, nil
+	case *lang.Pop:
+		tmp, err := g.genPop(v, true)
+		if err != nil {
+			return "", "", nil, false, err
+		}
+		return tmp, "", arr, true, nil
+	case *lang.Slice:
+		tmp, err := g.genNestedSlice(v)
+		if err != nil {
+			return "", "", nil, false, err
+		}
+		return tmp, "", arr, true, nil
+	default:
+		tmp := g.tmpVar()
+		if err := g.setNested(g.local("-A")+tmp+"=", tmp, arr, node); err != nil {
+			return "", "", nil, false, err
+		}
+		return tmp, "", arr, true, nil
+	}
+}
+
+// setNested writes an assignment of an array value to the associative array dst
+// lhs is written before the list of keys, for example `d=` or `declare -A d=`
+func (g *generator) setNested(lhs, dst string, arr *lang.ArrayType, value lang.Node) error {
+	var items []string
+	var post []func()
+	if err := g.arrayItems(&items, &post, dst, "", arr, value); err != nil {
+		return err
+	}
+
+	g.writeItems(lhs, items, post)
+	return nil
+}
+
+// storeAt writes an array value into the associative array name under the key prefix
+// any existing keys under the prefix are removed first when clear is set
+func (g *generator) storeAt(name, prefix string, arr *lang.ArrayType, value lang.Node, clear bool) error {
+	var items []string
+	var post []func()
+	if err := g.arrayItems(&items, &post, name, prefix, arr, value); err != nil {
+		return err
+	}
+
+	if clear {
+		g.clearKeys(name, prefix)
+	}
+
+	if len(items) > 0 {
+		g.line("%s+=( %s )", name, strings.Join(items, " "))
+	}
+	for _, fn := range post {
+		fn()
+	}
+
+	return nil
+}
+
+// writeItems writes an assignment of the list of keys followed by any code that copies the remaining values
+func (g *generator) writeItems(lhs string, items []string, post []func()) {
+	if len(items) == 0 {
+		g.line("%s()", lhs)
+	} else {
+		g.line("%s( %s )", lhs, strings.Join(items, " "))
+	}
+
+	for _, fn := range post {
+		fn()
+	}
+}
+
+// arrayItems adds the keys that store an array value in dst under the key prefix
+// values that are not literals are copied element by element after the assignment
+func (g *generator) arrayItems(items *[]string, post *[]func(), dst, prefix string, arr *lang.ArrayType, value lang.Node) error {
+	switch v := value.(type) {
+	case nil:
+		*items = append(*items, fmt.Sprintf("[%slen]=0", prefix))
+		return nil
+	case *lang.ArrayLit:
+		*items = append(*items, fmt.Sprintf("[%slen]=%d", prefix, len(v.Elems)))
+		for i, elem := range v.Elems {
+			key := prefix + strconv.Itoa(i)
+			if inner, ok := arr.Elem.(*lang.ArrayType); ok {
+				if err := g.arrayItems(items, post, dst, key+",", inner, elem); err != nil {
+					return err
+				}
+				continue
+			}
+
+			word, err := g.genWord(elem)
+			if err != nil {
+				return err
+			}
+			*items = append(*items, fmt.Sprintf("[%s]=%s", key, word))
+		}
+		return nil
+	}
+
+	if !isNested(arr) {
+		src, err := g.arrayVar(value)
+		if err != nil {
+			return err
+		}
+		*items = append(*items, fmt.Sprintf(`[%slen]="${#%s[@]}"`, prefix, src))
+		*post = append(*post, func() {
+			g.line(`for _bk_i in "${!%s[@]}"; do`, src)
+			g.line(`    %s[%s$_bk_i]="${%s[$_bk_i]}"`, dst, prefix, src)
+			g.line("done")
+		})
+		return nil
+	}
+
+	src, srcPrefix, _, ok, err := g.assocArray(value)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return genError(value, "expected a nested array value")
+	}
+	*post = append(*post, func() {
+		g.copyKeys(dst, prefix, src, srcPrefix)
+	})
+	return nil
+}
+
+// copyKeys writes a loop that copies every key under the src prefix to the same key under the dst prefix
+func (g *generator) copyKeys(dst, dstPrefix, src, srcPrefix string) {
+	g.line(`for _bk_k in "${!%s[@]}"; do`, src)
+	if srcPrefix == "" {
+		g.line(`    %s["%s${_bk_k}"]="${%s[$_bk_k]}"`, dst, dstPrefix, src)
+	} else {
+		g.line(`    if [[ "${_bk_k}" == "%s"* ]]; then`, srcPrefix)
+		g.line(`        %s["%s${_bk_k#"%s"}"]="${%s[$_bk_k]}"`, dst, dstPrefix, srcPrefix, src)
+		g.line("    fi")
+	}
+	g.line("done")
+}
+
+// copyRows writes a loop that copies the rows low to high of the nested array under the src prefix
+// the rows are renumbered so the row at low is stored at the row start under the dst prefix
+func (g *generator) copyRows(dst, dstPrefix, start, src, srcPrefix, low, high string) {
+	g.line(`for _bk_k in "${!%s[@]}"; do`, src)
+	g.line(`    _bk_r="${_bk_k#"%s"}"`, srcPrefix)
+	g.line(`    if [[ "${_bk_k}" == "%s"[0-9]* ]] && (( ${_bk_r%%%%,*} >= (%s) && ${_bk_r%%%%,*} < (%s) )); then`, srcPrefix, low, high)
+	g.line(`        %s["%s$(( ${_bk_r%%%%,*} - (%s) + %s )),${_bk_r#*,}"]="${%s[$_bk_k]}"`, dst, dstPrefix, low, start, src)
+	g.line("    fi")
+	g.line("done")
+}
+
+// clearKeys writes a loop that removes every key under the prefix
+func (g *generator) clearKeys(name, prefix string) {
+	g.line(`for _bk_k in "${!%s[@]}"; do`, name)
+	g.line(`    if [[ "${_bk_k}" == "%s"* ]]; then`, prefix)
+	g.line(`        unset "%s[${_bk_k}]"`, name)
+	g.line("    fi")
+	g.line("done")
+}
+
+// copyAssoc copies an array stored in an associative array into a temporary bash array
+func (g *generator) copyAssoc(name, prefix string) string {
+	tmp := g.tmpVar()
+	g.line("%s%s=()", g.local("-a"), tmp)
+	g.line(`for (( _bk_i=0; _bk_i<${%s[%slen]}; _bk_i++ )); do`, name, prefix)
+	g.line(`    %s+=( "${%s[%s$_bk_i]}" )`, tmp, name, prefix)
+	g.line("done")
+
+	return tmp
+}
+
+// flatten copies every element of a nested array into a temporary bash array in order
+func (g *generator) flatten(name, prefix string, arr *lang.ArrayType) string {
+	tmp := g.tmpVar()
+	g.line("%s%s=()", g.local("-a"), tmp)
+
+	depth := g.depth
+	var typ lang.Node = arr
+	for i := 0; lang.IsArray(typ); i++ {
+		g.line(`for (( _bk_i%d=0; _bk_i%d<${%s[%slen]}; _bk_i%d++ )); do`, i, i, name, prefix, i)
+		g.depth++
+		prefix += fmt.Sprintf("$_bk_i%d,", i)
+		typ = typ.(*lang.ArrayType).Elem
+	}
+
+	g.line(`%s+=( "${%s[%s]}" )`, tmp, name, strings.TrimSuffix(prefix, ","))
+	for g.depth > depth {
+		g.depth--
+		g.line("done")
+	}
+
+	return tmp
+}
+
+// genNestedSlice copies the rows of a nested array slice into a temporary associative array
+func (g *generator) genNestedSlice(s *lang.Slice) (string, error) {
+	src, prefix, _, _, err := g.assocArray(s.X)
+	if err != nil {
+		return "", err
+	}
+
+	low, high := "0", fmt.Sprintf("${%s[%slen]}", src, prefix)
+	if s.Low != nil {
+		low, err = g.genArith(s.Low)
+		if err != nil {
+			return "", err
+		}
+	}
+	if s.High != nil {
+		high, err = g.genArith(s.High)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	tmp := g.tmpVar()
+	g.line("%s%s=( [len]=$(( (%s) - (%s) )) )", g.local("-A"), tmp, high, low)
+	g.copyRows(tmp, "", "0", src, prefix, low, high)
+
+	return tmp, nil
+}
@@ -0,0 +1,34 @@
+package gen
+
+import (
+	"strings"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// genPipeline returns the command line for a pipeline
+// every stage runs in its own subshell so functions in a pipeline can only pass values through stdout,
+// functions with pipe inputs read them from the previous stage
+func (g *generator) genPipeline(pipe *lang.Pipeline) (string, error) {
+	var stages []string
+	for _, stage := range pipe.Stages {
+		switch v := stage.(type) {
+		case *lang.Exec:
+			cmd, err := g.genExec(v)
+			if err != nil {
+				return "", err
+			}
+			stages = append(stages, cmd)
+		case *lang.Call:
+			call, _, err := g.genCall(v)
+			if err != nil {
+				return "", err
+			}
+			stages = append(stages, call)
+		default:
+			return "", genError(stage, "only commands and function calls can be piped")
+		}
+	}
+
+	return strings.Join(stages, " | "), nil
+}
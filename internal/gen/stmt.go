@@ -0,0 +1,312 @@
+package gen
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// genStmt writes the bash code for a single statement
+func (g *generator) genStmt(node lang.Node) error {
+	g.readInputs(node)
+
+	defer func(copyRets bool) {
+		g.copyRets = copyRets
+	}(g.copyRets)
+	g.copyRets = countCalls(node) > 1
+
+	switch v := node.(type) {
+	case nil:
+		return nil
+	case *lang.Import:
+		return g.genImport(v)
+	case *lang.Var:
+		// functions declared inside of other functions are lowered to closures like function literals
+		// so they get a unique name and can capture the variables of the function they are declared in
+		if v.IsTypeDecl() {
+			return g.genTypeDecl(v)
+		}
+		return g.genVar(v)
+	case *lang.Destructure:
+		return g.genDestructure(v)
+	case *lang.Assign:
+		return g.genAssign(v)
+	case *lang.Exec:
+		cmd, err := g.genExec(v)
+		if err != nil {
+			return err
+		}
+		g.line("%s", cmd)
+		return nil
+	case *lang.Pipeline:
+		cmd, err := g.genPipeline(v)
+		if err != nil {
+			return err
+		}
+		g.line("%s", cmd)
+		return nil
+	case *lang.Call:
+		call, ref, err := g.genCall(v)
+		if err != nil {
+			return err
+		}
+		g.writeCall(call, ref)
+		return nil
+	case *lang.IncDec:
+		return g.genIncDec(v)
+	case *lang.Append:
+		return g.genAppend(v)
+	case *lang.Pop:
+		_, err := g.genPop(v, false)
+		return err
+	case *lang.Loop:
+		return g.genLoop(v)
+	case *lang.If:
+		return g.genIf(v)
+	case *lang.Return:
+		return g.genReturn(v)
+	case *lang.Comment:
+		g.line("#%s", v.Value)
+		return nil
+	default:
+		return invalidNode(node)
+	}
+}
+
+// countCalls returns the number of calls in a statement, calls in nested blocks and functions are not counted
+func countCalls(node lang.Node) int {
+	switch node.(type) {
+	case nil, *lang.Block, *lang.Func:
+		return 0
+	}
+
+	n := 0
+	if _, ok := node.(*lang.Call); ok {
+		n++
+	}
+	for _, child := range node.Children() {
+		n += countCalls(child)
+	}
+
+	return n
+}
+
+// genBlock writes each statement in the block inside of a new scope
+func (g *generator) genBlock(block *lang.Block) error {
+	g.pushScope()
+	defer g.popScope()
+
+	for _, stmt := range block.Stmts {
+		if err := g.genStmt(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// genImport records the import so later references can be renamed and writes a check that the command exists
+// aliases are renamed at compile time so only the imported command is checked for
+func (g *generator) genImport(imp *lang.Import) error {
+	name := imp.Name
+	if imp.As != "" {
+		name = imp.As
+	}
+	g.imports[name] = imp.Name
+
+	if g.checked[imp.Name] {
+		return nil
+	}
+
+	// every command gets its own exit code in import order, bash exit codes can not be larger than 255
+	code := g.client.importExit + len(g.checked)
+	if code > 255 {
+		code = 255
+	}
+	g.checked[imp.Name] = true
+
+	g.line("if ! command -v %s > /dev/null; then", imp.Name)
+	g.depth++
+	g.line("echo \"imported command %s could not be found\" >&2", imp.Name)
+	g.line("exit %d", code)
+	g.depth--
+	g.line("fi")
+
+	return nil
+}
+
+// genVar writes a variable declaration, variables without a default are set to their zero value
+func (g *generator) genVar(v *lang.Var) error {
+	if v.Name == "me" {
+		return genError(v, "me is reserved for the receiver of a method")
+	}
+
+	typ, err := g.declType(v)
+	if err != nil {
+		return err
+	}
+
+	if st, ok := g.scope.StructType(typ); ok {
+		return g.genStructVar(&lang.Var{Name: v.Name, Type: typ, Default: v.Default, Token: v.Token}, st)
+	}
+
+	if isNested(typ) {
+		if err := g.setNested(g.local("-A")+v.Name+"=", v.Name, typ.(*lang.ArrayType), v.Default); err != nil {
+			return err
+		}
+		g.scope.declare(v.Name, typ)
+		return nil
+	}
+
+	if v.Default == nil {
+		g.scope.declare(v.Name, typ)
+		g.line("%s%s=%s", g.local(listFlags(typ)), v.Name, zeroValue(typ))
+		return nil
+	}
+
+	var value string
+	if fn, ok := v.Default.(*lang.Func); ok {
+		// a function declared inside of another function can call itself by its name
+		value, err = g.genClosure(fn, v.Name)
+	} else {
+		value, err = g.genValue(v.Default)
+	}
+	if err != nil {
+		return err
+	}
+
+	// declare the variable after the value is generated so it can not reference itself
+	g.scope.declare(v.Name, typ)
+	g.line("%s%s=%s", g.local(listFlags(typ)), v.Name, value)
+	return nil
+}
+
+// genDestructure writes a declaration that unpacks the return values of a function
+func (g *generator) genDestructure(d *lang.Destructure) error {
+	call, ok := d.Value.(*lang.Call)
+	if !ok {
+		return genError(d.Value, "only function calls can be destructured")
+	}
+
+	line, ref, err := g.genCall(call)
+	if err != nil {
+		return err
+	}
+
+	if len(ref.sig.Returns) != len(d.Names) {
+		return genError(d, fmt.Sprintf("cannot assign %d return values to %d variables", len(ref.sig.Returns), len(d.Names)))
+	}
+
+	g.writeCall(line, ref)
+	for i, ident := range d.Names {
+		typ := ref.sig.Returns[i]
+		src := g.retRef(ref, fmt.Sprintf("_%d", i), typ)
+
+		if st, ok := g.scope.StructType(typ); ok {
+			var items []string
+			var post []func()
+			g.copyItems(&items, &post, ident.Name, "", st, src, "")
+			g.writeItems(g.local("-A")+ident.Name+"=", items, post)
+		} else if isNested(typ) {
+			g.line("%s%s=()", g.local("-A"), ident.Name)
+			g.copyKeys(ident.Name, "", src, "")
+		} else {
+			g.line("%s%s=%s", g.local(listFlags(typ)), ident.Name, copyValue(typ, src))
+		}
+		g.scope.declare(ident.Name, typ)
+	}
+
+	return nil
+}
+
+// genAssign writes an assignment to an existing variable or struct field
+func (g *generator) genAssign(a *lang.Assign) error {
+	switch v := a.Target.(type) {
+	case *lang.Field:
+		return g.genFieldAssign(v, a.Value)
+	case *lang.Index:
+		name, sub, typ, err := g.elemRef(v)
+		if err != nil {
+			return err
+		}
+		// elements of nested arrays are arrays stored under the element key
+		if arr, ok := typ.(*lang.ArrayType); ok {
+			return g.storeAt(name, sub+",", arr, a.Value, true)
+		}
+		if lang.IsArray(typ) || g.isStruct(typ) {
+			return genError(v, "cannot assign to this element")
+		}
+		word, err := g.genWord(a.Value)
+		if err != nil {
+			return err
+		}
+		g.line("%s[%s]=%s", name, sub, word)
+		return nil
+	}
+
+	target, ok := a.Target.(*lang.Ident)
+	if !ok {
+		return genError(a.Target, "cannot assign to this expression")
+	}
+
+	typ, ok := g.scope.Lookup(target.Name)
+	if !ok {
+		return undefined(target)
+	}
+
+	if st, ok := g.scope.StructType(typ); ok {
+		return g.setStruct(target.Name+"=", target.Name, "", st, a.Value)
+	}
+
+	if isNested(typ) {
+		return g.setNested(target.Name+"=", target.Name, typ.(*lang.ArrayType), a.Value)
+	}
+
+	value, err := g.genValue(a.Value)
+	if err != nil {
+		return err
+	}
+
+	g.line("%s=%s", target.Name, value)
+	return nil
+}
+
+// genIncDec writes an increment or decrement of an int variable, field or array element
+func (g *generator) genIncDec(n *lang.IncDec) error {
+	op := n.Op[:1]
+
+	switch v := n.X.(type) {
+	case *lang.Ident:
+		typ, ok := g.scope.Lookup(v.Name)
+		if !ok {
+			return undefined(v)
+		}
+		if !lang.IsInt(typ) {
+			return genError(n, "the "+n.Op+" operator can only be used on ints")
+		}
+		g.line("%s=$(( %s %s 1 ))", v.Name, v.Name, op)
+		return nil
+	case *lang.Field:
+		name, key, typ, err := g.fieldRef(v)
+		if err != nil {
+			return err
+		}
+		if !lang.IsInt(typ) {
+			return genError(n, "the "+n.Op+" operator can only be used on ints")
+		}
+		g.line("%s[%s]=$(( ${%s[%s]} %s 1 ))", name, key, name, key, op)
+		return nil
+	case *lang.Index:
+		name, sub, typ, err := g.elemRef(v)
+		if err != nil {
+			return err
+		}
+		if !lang.IsInt(typ) {
+			return genError(n, "the "+n.Op+" operator can only be used on ints")
+		}
+		g.line("%s[%s]=$(( ${%s[%s]} %s 1 ))", name, sub, name, sub, op)
+		return nil
+	default:
+		return genError(n, "the "+n.Op+" operator can only be used on variables")
+	}
+}
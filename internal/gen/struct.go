@@ -0,0 +1,312 @@
+package gen
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// structs are stored in bash associative arrays, nested fields use dotted keys and
+// array fields store their length in a len key followed by one key per element
+//
+//	declare -A a=( [pos.x]="1" [pos.y]="2" [tags.len]="1" [tags.0]="hi" )
+
+// methodName returns the name of the bash function for a struct method
+func methodName(structName, method string) string {
+	return structName + "_" + method
+}
+
+// isStruct returns true if the type is stored in a bash associative array
+func (g *generator) isStruct(typ lang.Node) bool {
+	_, ok := g.scope.StructType(typ)
+	return ok
+}
+
+// genTypeDecl declares a named struct type and writes its methods
+func (g *generator) genTypeDecl(v *lang.Var) error {
+	st := v.Default.(*lang.StructType)
+	g.scope.DeclareType(v.Name, st)
+
+	return g.genMethods(v.Name, st)
+}
+
+// genMethods writes a bash function for each method of a struct type
+func (g *generator) genMethods(name string, st *lang.StructType) error {
+	for _, method := range st.Methods {
+		if err := g.genFunc(methodName(name, method.Name), method.Default.(*lang.Func), &lang.TypeName{Name: name}); err != nil {
+			return err
+		}
+		g.line("")
+	}
+
+	return nil
+}
+
+// genStructVar writes the declaration of a struct variable
+// variables declared inside of functions are local like all other variables
+func (g *generator) genStructVar(v *lang.Var, st *lang.StructType) error {
+	if err := g.setStruct(g.local("-A")+v.Name+"=", v.Name, "", st, v.Default); err != nil {
+		return err
+	}
+
+	g.scope.declare(v.Name, v.Type)
+	return nil
+}
+
+// setStruct writes an assignment of a struct value to the fields of dst under the key prefix
+// lhs is written before the list of keys, for example `a=` or `a+=`
+func (g *generator) setStruct(lhs, dst, prefix string, st *lang.StructType, value lang.Node) error {
+	var items []string
+	var post []func()
+
+	switch value.(type) {
+	case nil, *lang.StructLit:
+		lit, _ := value.(*lang.StructLit)
+		if err := g.structItems(&items, &post, dst, prefix, st, lit); err != nil {
+			return err
+		}
+	default:
+		src, srcPrefix, srcType, err := g.structRef(value)
+		if err != nil {
+			return err
+		}
+		if srcType != st {
+			return genError(value, "mismatched struct types")
+		}
+		g.copyItems(&items, &post, dst, prefix, st, src, srcPrefix)
+	}
+
+	g.writeItems(lhs, items, post)
+	return nil
+}
+
+// structItems adds the keys for a struct literal, fields missing from the literal use their default value
+// array fields are copied element by element after the assignment
+func (g *generator) structItems(items *[]string, post *[]func(), dst, prefix string, st *lang.StructType, lit *lang.StructLit) error {
+	if lit != nil {
+		for _, kv := range lit.Fields {
+			if _, ok := st.Field(kv.Key.Name); !ok {
+				return genError(kv.Key, "unknown field "+kv.Key.Name)
+			}
+		}
+	}
+
+	for _, field := range st.Fields {
+		value := field.Default
+		if lit != nil {
+			if v, ok := lit.Field(field.Name); ok {
+				value = v
+			}
+		}
+
+		key := prefix + field.Name
+		switch {
+		case lang.IsCmd(field.Type), lang.IsFn(field.Type):
+			return genError(field, "cmds and fn values can not be stored in a struct")
+		case g.isStruct(field.Type):
+			fst, _ := g.scope.StructType(field.Type)
+			switch value.(type) {
+			case nil, *lang.StructLit:
+				lit, _ := value.(*lang.StructLit)
+				if err := g.structItems(items, post, dst, key+".", fst, lit); err != nil {
+					return err
+				}
+			default:
+				src, srcPrefix, srcType, err := g.structRef(value)
+				if err != nil {
+					return err
+				}
+				if srcType != fst {
+					return genError(value, "mismatched struct types")
+				}
+				g.copyItems(items, post, dst, key+".", fst, src, srcPrefix)
+			}
+		case lang.IsArray(field.Type):
+			if err := g.arrayItems(items, post, dst, key+".", field.Type.(*lang.ArrayType), value); err != nil {
+				return err
+			}
+		default:
+			word := zeroValue(field.Type)
+			if value != nil {
+				var err error
+				word, err = g.genWord(value)
+				if err != nil {
+					return err
+				}
+			}
+			*items = append(*items, fmt.Sprintf("[%s]=%s", key, word))
+		}
+	}
+
+	return nil
+}
+
+// copyItems adds the keys that copy every field of the src struct into dst
+func (g *generator) copyItems(items *[]string, post *[]func(), dst, prefix string, st *lang.StructType, src, srcPrefix string) {
+	for _, field := range st.Fields {
+		key, srcKey := prefix+field.Name, srcPrefix+field.Name
+
+		switch {
+		case g.isStruct(field.Type):
+			fst, _ := g.scope.StructType(field.Type)
+			g.copyItems(items, post, dst, key+".", fst, src, srcKey+".")
+		case lang.IsArray(field.Type):
+			*post = append(*post, func() {
+				g.copyKeys(dst, key+".", src, srcKey+".")
+			})
+		default:
+			*items = append(*items, fmt.Sprintf(`[%s]="${%s[%s]}"`, key, src, srcKey))
+		}
+	}
+}
+
+// structRef returns the associative array and key prefix that hold a struct value
+// calls are written out so their return value can be referenced
+func (g *generator) structRef(node lang.Node) (string, string, *lang.StructType, error) {
+	switch v := node.(type) {
+	case *lang.Ident:
+		typ, ok := g.scope.Lookup(v.Name)
+		if !ok {
+			return "", "", nil, undefined(v)
+		}
+
+		st, ok := g.scope.StructType(typ)
+		if !ok {
+			return "", "", nil, genError(v, v.SourceName()+" is not a struct")
+		}
+
+		return v.Name, "", st, nil
+	case *lang.Field:
+		name, key, typ, err := g.fieldRef(v)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		st, ok := g.scope.StructType(typ)
+		if !ok {
+			return "", "", nil, genError(v, key+" is not a struct")
+		}
+
+		return name, key + ".", st, nil
+	case *lang.Call:
+		typ, err := g.typeOf(v)
+		if err != nil {
+			return "", "", nil, err
+		}
+
+		st, ok := g.scope.StructType(typ)
+		if !ok {
+			return "", "", nil, genError(v, "the function does not return a struct")
+		}
+
+		ret, _, err := g.genCallValue(v)
+		if err != nil {
+			return "", "", nil, err
+		}
+		return ret, "", st, nil
+	default:
+		return "", "", nil, genError(node, "expected a struct value")
+	}
+}
+
+// fieldRef returns the associative array, key and type of a struct field
+func (g *generator) fieldRef(field *lang.Field) (string, string, lang.Node, error) {
+	name, prefix, st, err := g.structRef(field.X)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	f, ok := st.Field(field.Name.Name)
+	if !ok {
+		return "", "", nil, genError(field.Name, "unknown field "+field.Name.Name)
+	}
+
+	return name, prefix + f.Name, f.Type, nil
+}
+
+// genRecv returns the word that passes a method receiver by name
+func (g *generator) genRecv(recv lang.Node) (string, error) {
+	ident, ok := recv.(*lang.Ident)
+	if !ok {
+		return "", genError(recv, "methods can only be called on struct variables")
+	}
+
+	if _, ok := g.scope.Lookup(ident.Name); !ok {
+		return "", undefined(ident)
+	}
+
+	// pass the name of the referenced variable so the callee does not create a circular reference
+	if g.fn != nil && g.fn.refs[ident.Name] {
+		return fmt.Sprintf(`"${!%s}"`, ident.Name), nil
+	}
+
+	// the method declares its own me before copying the receiver so me is passed as a copy
+	if ident.Name == "me" {
+		tmp := g.tmpVar()
+		g.line("%s%s=()", g.local("-A"), tmp)
+		g.copyKeys(tmp, "", "me", "")
+		return tmp, nil
+	}
+
+	return ident.Name, nil
+}
+
+// genFieldAssign writes an assignment to a struct field
+func (g *generator) genFieldAssign(field *lang.Field, value lang.Node) error {
+	name, key, typ, err := g.fieldRef(field)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case g.isStruct(typ):
+		st, _ := g.scope.StructType(typ)
+		return g.setStruct(name+"+=", name, key+".", st, value)
+	case lang.IsArray(typ):
+		return g.storeAt(name, key+".", typ.(*lang.ArrayType), value, true)
+	default:
+		word, err := g.genWord(value)
+		if err != nil {
+			return err
+		}
+		g.line("%s[%s]=%s", name, key, word)
+		return nil
+	}
+}
+
+// arrayVar returns the name of a bash array that holds the array value
+// values that are not already stored in a variable are copied into a temporary variable
+func (g *generator) arrayVar(node lang.Node) (string, error) {
+	typ, err := g.typeOf(node)
+	if err != nil {
+		return "", err
+	}
+
+	if !lang.IsArray(typ) {
+		return "", genError(node, "expected an array value")
+	}
+	if isNested(typ) {
+		return "", genError(node, "nested arrays can not be stored in a bash array")
+	}
+
+	if ident, ok := node.(*lang.Ident); ok {
+		return ident.Name, nil
+	}
+
+	name, prefix, _, ok, err := g.assocArray(node)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return g.copyAssoc(name, prefix), nil
+	}
+
+	value, err := g.genValue(node)
+	if err != nil {
+		return "", err
+	}
+
+	tmp := g.tmpVar()
+	g.line("%s%s=%s", g.local("-a"), tmp, value)
+	return tmp, nil
+}
@@ -0,0 +1,151 @@
+package gen
+
+import (
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// typeOf returns the type of a value node
+func (g *generator) typeOf(node lang.Node) (lang.Node, error) {
+	switch v := node.(type) {
+	case *lang.String, *lang.Exec, *lang.Pipeline:
+		return &lang.TypeName{Name: "string"}, nil
+	case *lang.Int:
+		return &lang.TypeName{Name: "int"}, nil
+	case *lang.Bool, *lang.Unary:
+		return &lang.TypeName{Name: "bool"}, nil
+	case *lang.Binary:
+		if lang.IsComparison(v.Op) || lang.IsLogical(v.Op) {
+			return &lang.TypeName{Name: "bool"}, nil
+		}
+		x, err := g.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := g.typeOf(v.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !(lang.IsInt(x) && lang.IsInt(y)) && !(lang.IsString(x) && lang.IsString(y) && v.Op == "+") {
+			return nil, genError(v, "the "+v.Op+" operator is not defined for these values")
+		}
+		return x, nil
+	case *lang.Ident:
+		typ, ok := g.scope.Lookup(v.Name)
+		if !ok {
+			return nil, undefined(v)
+		}
+		return typ, nil
+	case *lang.Func:
+		return v.Type, nil
+	case *lang.Cmd:
+		return &lang.TypeName{Name: "cmd"}, nil
+	case *lang.Call:
+		ref, err := g.lookupFunc(v.Fn)
+		if err != nil {
+			return nil, err
+		}
+		if len(ref.sig.Returns) == 0 {
+			return nil, genError(v, "function call has no value")
+		}
+		return ref.sig.Returns[0], nil
+	case *lang.Field:
+		typ, err := g.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		st, ok := g.scope.StructType(typ)
+		if !ok {
+			return nil, genError(v, "only structs have fields")
+		}
+		field, ok := st.Field(v.Name.Name)
+		if !ok {
+			return nil, genError(v.Name, "unknown field "+v.Name.Name)
+		}
+		return field.Type, nil
+	case *lang.StructLit:
+		return nil, genError(v, "struct literals must be declared with a type")
+	case *lang.ArrayLit:
+		if len(v.Elems) == 0 {
+			return nil, genError(v, "empty array literals must be declared with a type")
+		}
+		elem, err := g.typeOf(v.Elems[0])
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range v.Elems[1:] {
+			typ, err := g.typeOf(e)
+			if err != nil {
+				return nil, err
+			}
+			if !lang.SameType(elem, typ) {
+				return nil, genError(e, "array elements must all be the same type")
+			}
+		}
+		return &lang.ArrayType{Elem: elem}, nil
+	case *lang.Index:
+		if _, ok := g.cmdLit(v); ok {
+			return &lang.TypeName{Name: "cmd"}, nil
+		}
+		x, err := g.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		if lang.IsCmd(x) {
+			return &lang.TypeName{Name: "string"}, nil
+		}
+		arr, ok := x.(*lang.ArrayType)
+		if !ok {
+			return nil, genError(v, "only arrays can be indexed")
+		}
+		return arr.Elem, nil
+	case *lang.Slice:
+		x, err := g.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		if !lang.IsArray(x) && !lang.IsCmd(x) {
+			return nil, genError(v, "only arrays and cmds can be sliced")
+		}
+		return x, nil
+	case *lang.Len:
+		return &lang.TypeName{Name: "int"}, nil
+	case *lang.Pop:
+		x, err := g.typeOf(v.X)
+		if err != nil {
+			return nil, err
+		}
+		if lang.IsCmd(x) {
+			return &lang.TypeName{Name: "string"}, nil
+		}
+		arr, ok := x.(*lang.ArrayType)
+		if !ok {
+			return nil, genError(v, "only arrays and cmds can be popped")
+		}
+		return arr.Elem, nil
+	default:
+		return nil, invalidNode(node)
+	}
+}
+
+// declType returns the type of a variable declaration, inferring it from the default value if needed
+func (g *generator) declType(v *lang.Var) (lang.Node, error) {
+	if v.Type != nil {
+		return v.Type, nil
+	}
+
+	return g.typeOf(v.Default)
+}
+
+// zeroValue returns the right hand side of an assignment that sets a variable of the given type to its zero value
+func zeroValue(typ lang.Node) string {
+	switch {
+	case lang.IsArray(typ), lang.IsCmd(typ), lang.IsFn(typ):
+		return "()"
+	case lang.IsInt(typ):
+		return "0"
+	case lang.IsBool(typ):
+		return "false"
+	default:
+		return `""`
+	}
+}
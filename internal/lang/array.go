@@ -0,0 +1,213 @@
+package lang
+
+import (
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+type ArrayLit struct {
+	Elems []Node
+	Token lex.Token
+}
+
+func (n *ArrayLit) Children() []Node {
+	return n.Elems
+}
+
+func (n *ArrayLit) Pos() lex.Token {
+	return n.Token
+}
+
+type Index struct {
+	X     Node
+	Index Node
+	Token lex.Token
+}
+
+func (n *Index) Children() []Node {
+	return []Node{n.X, n.Index}
+}
+
+func (n *Index) Pos() lex.Token {
+	return n.Token
+}
+
+// Slice is a sub array, Low and High are nil when they are left out
+type Slice struct {
+	X     Node
+	Low   Node
+	High  Node
+	Token lex.Token
+}
+
+func (n *Slice) Children() []Node {
+	return []Node{n.X, n.Low, n.High}
+}
+
+func (n *Slice) Pos() lex.Token {
+	return n.Token
+}
+
+type Append struct {
+	X     Node
+	Value Node
+	Token lex.Token
+}
+
+func (n *Append) Children() []Node {
+	return []Node{n.X, n.Value}
+}
+
+func (n *Append) Pos() lex.Token {
+	return n.Token
+}
+
+type Pop struct {
+	X     Node
+	Token lex.Token
+}
+
+func (n *Pop) Children() []Node {
+	return []Node{n.X}
+}
+
+func (n *Pop) Pos() lex.Token {
+	return n.Token
+}
+
+// Len is the number of elements in an array
+type Len struct {
+	X     Node
+	Token lex.Token
+}
+
+func (n *Len) Children() []Node {
+	return []Node{n.X}
+}
+
+func (n *Len) Pos() lex.Token {
+	return n.Token
+}
+
+// compoundOps maps each compound assignment to the binary operator it applies
+var compoundOps = map[lex.TokType]string{
+	lex.PlusEqual:    "+",
+	lex.MinusEqual:   "-",
+	lex.StarEqual:    "*",
+	lex.SlashEqual:   "/",
+	lex.PercentEqual: "%",
+}
+
+// parseArrayLit parses an array literal, elements may be split across lines
+//
+//	[1, 2, 3]
+func (p *parser) parseArrayLit() (*ArrayLit, error) {
+	defer p.group()()
+
+	open, err := p.expect(lex.OpenSquare)
+	if err != nil {
+		return nil, err
+	}
+
+	lit := &ArrayLit{Token: open}
+	for {
+		p.skip(lex.SemiColon)
+		if _, ok := p.accept(lex.CloseSquare); ok {
+			return lit, nil
+		}
+
+		elem, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		lit.Elems = append(lit.Elems, elem)
+
+		p.skip(lex.SemiColon)
+		if _, ok := p.accept(lex.Comma); !ok && !p.is(lex.CloseSquare) {
+			return nil, syntaxError(p.peek(0), "expected "+lex.CloseSquare.String())
+		}
+	}
+}
+
+// parseIndex parses an index or a slice of x, either side of a slice can be left out
+//
+//	a[1]
+//	a[1:3]
+//	a[:3]
+//
+// a name followed by more than one value is a cmd
+//
+//	echo["hello", "world"]
+func (p *parser) parseIndex(x Node) (Node, error) {
+	defer p.group()()
+
+	open, err := p.expect(lex.OpenSquare)
+	if err != nil {
+		return nil, err
+	}
+
+	var low Node
+	if !p.is(lex.Colon) {
+		low, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if name, ok := x.(*Ident); ok && low != nil && p.is(lex.Comma) {
+		p.next()
+		args, err := p.parseArgs(lex.CloseSquare)
+		if err != nil {
+			return nil, err
+		}
+		return &Cmd{Name: name, Args: append([]Node{low}, args...), Token: name.Token}, nil
+	}
+
+	if _, ok := p.accept(lex.CloseSquare); ok {
+		if low == nil {
+			return nil, syntaxError(open, "missing index")
+		}
+		return &Index{X: x, Index: low, Token: open}, nil
+	}
+
+	if _, err := p.expect(lex.Colon); err != nil {
+		return nil, err
+	}
+
+	slice := &Slice{X: x, Low: low, Token: open}
+	if !p.is(lex.CloseSquare) {
+		slice.High, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.expect(lex.CloseSquare); err != nil {
+		return nil, err
+	}
+
+	return slice, nil
+}
+
+// parseLen parses the length of an array
+//
+//	len(a)
+func (p *parser) parseLen() (*Len, error) {
+	tok, err := p.expect(lex.Identifyer)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.expect(lex.OpenParen); err != nil {
+		return nil, err
+	}
+
+	args, err := p.parseArgs(lex.CloseParen)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 1 {
+		return nil, syntaxError(tok, "len takes a single array")
+	}
+
+	return &Len{X: args[0], Token: tok}, nil
+}
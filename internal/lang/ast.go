@@ -1,7 +1,6 @@
 package lang
 
 import (
-	"github.com/bjatkin/bear"
 	"github.com/bjatkin/blow-k/internal/errors"
 	"github.com/bjatkin/blow-k/internal/lex"
 )
@@ -17,23 +16,34 @@ func NewClient() *Client {
 				match: MatchImport,
 				new:   NewImport,
 			},
+			{
+				match: MatchVar,
+				new:   NewVar,
+			},
 		},
 	}
 }
 
+// Build parses the tokens into the root node of a blowK program
+// every import and declaration is parsed even if an earlier one fails so all the syntax errors are reported at once
 func (c *Client) Build(tokens []lex.Token) (Node, error) {
 	root := &Root{}
+	diags := errors.NewCollector(0)
 
 	exprs := c.getExpressions(tokens)
 	for _, expr := range exprs {
+		if isEmpty(expr) {
+			continue
+		}
+
+		matched := false
 		for _, matcher := range c.matchers {
 			if matcher.match(expr) {
+				matched = true
 				node, err := matcher.new(expr)
 				if err != nil {
-					return nil, errors.Wrap(err,
-						bear.WithErrType(errors.SyntaxError),
-						bear.WithExitCode(errors.ASTFailed),
-					)
+					diags.Add(err)
+					continue
 				}
 
 				switch v := node.(type) {
@@ -46,13 +56,18 @@ func (c *Client) Build(tokens []lex.Token) (Node, error) {
 						root.Expressions = append(root.Expressions, v)
 					}
 				default:
-					return nil, errors.New(
-						bear.WithErrType(errors.SyntaxError),
-						bear.WithExitCode(errors.ASTFailed),
-					)
+					diags.Add(syntaxError(node.Pos(), "expected an import or declaration"))
 				}
 			}
 		}
+
+		if !matched {
+			diags.Add(syntaxError(newParser(expr).peek(0), "expected an import or declaration"))
+		}
+	}
+
+	if err := diags.Err(); err != nil {
+		return nil, err
 	}
 
 	return root, nil
@@ -63,42 +78,65 @@ func (c *Client) Build(tokens []lex.Token) (Node, error) {
 func (c *Client) getExpressions(tokens []lex.Token) [][]lex.Token {
 	var blocks [][]lex.Token
 	var collect []lex.Token
-	var openParenCount, openBraceCount, openSquareCount int
+	var open []lex.TokType
 
-	for _, token := range tokens {
+	for i, token := range tokens {
 		collect = append(collect, token)
 
-		if token.T == lex.OpenParen {
-			openParenCount++
-		}
-		if token.T == lex.OpenBrace {
-			openBraceCount++
-		}
-		if token.T == lex.OpenSquare {
-			openSquareCount++
-		}
-		if token.T == lex.CloseParen {
-			openParenCount--
-		}
-		if token.T == lex.CloseBrace {
-			openBraceCount--
-		}
-		if token.T == lex.CloseSquare {
-			openSquareCount--
+		switch token.T {
+		case lex.OpenParen, lex.OpenBrace, lex.OpenSquare:
+			open = append(open, token.T)
+		case lex.LessThan:
+			// a < is only a bracket when it opens a struct type or a list of return types
+			if i > 0 && (tokens[i-1].T == lex.Colon || tokens[i-1].T == lex.DoubleColon ||
+				tokens[i-1].T == lex.CloseParen) {
+				open = append(open, token.T)
+			}
+		case lex.CloseParen, lex.CloseBrace, lex.CloseSquare, lex.GreaterThan:
+			if len(open) > 0 && open[len(open)-1] == opener(token.T) {
+				open = open[:len(open)-1]
+			}
 		}
 
-		if token.T == lex.SemiColon &&
-			openParenCount == 0 &&
-			openBraceCount == 0 &&
-			openSquareCount == 0 {
+		if token.T == lex.SemiColon && len(open) == 0 {
 			blocks = append(blocks, collect)
 			collect = []lex.Token{}
 		}
 	}
 
+	// make sure to get the final expression even if it was not terminated
+	if len(collect) > 0 {
+		blocks = append(blocks, collect)
+	}
+
 	return blocks
 }
 
+// opener returns the token type that opens the bracket closed by t
+func opener(t lex.TokType) lex.TokType {
+	switch t {
+	case lex.CloseParen:
+		return lex.OpenParen
+	case lex.CloseBrace:
+		return lex.OpenBrace
+	case lex.CloseSquare:
+		return lex.OpenSquare
+	default:
+		return lex.LessThan
+	}
+}
+
+// isEmpty returns true if the expression block only contains comments and semicolons
+func isEmpty(tokens []lex.Token) bool {
+	for _, token := range tokens {
+		if token.T != lex.Comment && token.T != lex.SemiColon {
+			return false
+		}
+	}
+
+	return true
+}
+
 // match, matches an express block and uses it to create a node
 type matcher struct {
 	match func([]lex.Token) bool
@@ -50,15 +50,16 @@ func Test_getEpresssions(t *testing.T) {
 			args{
 				tokens: []lex.Token{
 					{T: lex.Identifyer, Value: "fiz"},
-					{T: lex.Colon, Value: ":"},
-					{T: lex.Colon, Value: ":"},
+					{T: lex.DoubleColon, Value: "::"},
 					{T: lex.String, Value: "buz"},
 					{T: lex.SemiColon, Value: ";"},
 					{T: lex.Identifyer, Value: "main"},
 					{T: lex.Colon, Value: ":"},
 					{T: lex.OpenParen, Value: "("},
 					{T: lex.Identifyer, Value: "args"},
-					{T: lex.StringArrayType, Value: "[]string"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.StringType, Value: "string"},
 					{T: lex.CloseParen, Value: ")"},
 					{T: lex.Colon, Value: ":"},
 					{T: lex.OpenBrace, Value: "{"},
@@ -69,8 +70,7 @@ func Test_getEpresssions(t *testing.T) {
 			[][]lex.Token{
 				{
 					{T: lex.Identifyer, Value: "fiz"},
-					{T: lex.Colon, Value: ":"},
-					{T: lex.Colon, Value: ":"},
+					{T: lex.DoubleColon, Value: "::"},
 					{T: lex.String, Value: "buz"},
 					{T: lex.SemiColon, Value: ";"},
 				},
@@ -79,7 +79,9 @@ func Test_getEpresssions(t *testing.T) {
 					{T: lex.Colon, Value: ":"},
 					{T: lex.OpenParen, Value: "("},
 					{T: lex.Identifyer, Value: "args"},
-					{T: lex.StringArrayType, Value: "[]string"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.StringType, Value: "string"},
 					{T: lex.CloseParen, Value: ")"},
 					{T: lex.Colon, Value: ":"},
 					{T: lex.OpenBrace, Value: "{"},
@@ -88,6 +90,47 @@ func Test_getEpresssions(t *testing.T) {
 				},
 			},
 		},
+		{
+			"multi line struct type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.Identifyer, Value: "x"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			[][]lex.Token{
+				{
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.Identifyer, Value: "x"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+				{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
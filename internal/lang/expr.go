@@ -0,0 +1,128 @@
+package lang
+
+import (
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+type Int struct {
+	Value string
+	Token lex.Token
+}
+
+func (n *Int) Children() []Node {
+	return nil
+}
+
+func (n *Int) Pos() lex.Token {
+	return n.Token
+}
+
+type Bool struct {
+	Value bool
+	Token lex.Token
+}
+
+func (n *Bool) Children() []Node {
+	return nil
+}
+
+func (n *Bool) Pos() lex.Token {
+	return n.Token
+}
+
+type Binary struct {
+	Op    string
+	X     Node
+	Y     Node
+	Token lex.Token
+}
+
+func (n *Binary) Children() []Node {
+	return []Node{n.X, n.Y}
+}
+
+func (n *Binary) Pos() lex.Token {
+	return n.Token
+}
+
+type Unary struct {
+	Op    string
+	X     Node
+	Token lex.Token
+}
+
+func (n *Unary) Children() []Node {
+	return []Node{n.X}
+}
+
+func (n *Unary) Pos() lex.Token {
+	return n.Token
+}
+
+type IncDec struct {
+	Op    string
+	X     Node
+	Token lex.Token
+}
+
+func (n *IncDec) Children() []Node {
+	return []Node{n.X}
+}
+
+func (n *IncDec) Pos() lex.Token {
+	return n.Token
+}
+
+// binaryOps maps each binary operator to its precedence, higher values bind tighter
+var binaryOps = map[lex.TokType]int{
+	lex.Or:          1,
+	lex.And:         2,
+	lex.DoubleEqual: 3, lex.NotEqual: 3, lex.LessThan: 3, lex.LessEqual: 3, lex.GreaterThan: 3, lex.GreaterEqual: 3,
+	lex.Plus: 4, lex.Minus: 4,
+	lex.Star: 5, lex.Slash: 5, lex.Percent: 5,
+	lex.Power: 6,
+}
+
+// parseBinary parses a binary expression where every operator has at least the given precedence
+func (p *parser) parseBinary(prec int) (Node, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		opPrec, ok := binaryOps[p.peek(0).T]
+		if !ok || opPrec < prec || (p.fieldDefault && p.is(lex.GreaterThan)) {
+			return x, nil
+		}
+
+		tok := p.next()
+
+		// ** is right associative so the right operand may use the same operator
+		next := opPrec + 1
+		if tok.T == lex.Power {
+			next = opPrec
+		}
+
+		y, err := p.parseBinary(next)
+		if err != nil {
+			return nil, err
+		}
+
+		x = &Binary{Op: tok.Value, X: x, Y: y, Token: tok}
+	}
+}
+
+// parseUnary parses an expression with any number of leading ! operators
+func (p *parser) parseUnary() (Node, error) {
+	if tok, ok := p.accept(lex.Bang); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Unary{Op: tok.Value, X: x, Token: tok}, nil
+	}
+
+	return p.parsePostfix()
+}
@@ -0,0 +1,311 @@
+package lang
+
+import (
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+type FuncType struct {
+	Params  []*Var
+	Inputs  []*Ident
+	Returns []Node
+	Token   lex.Token
+}
+
+func (n *FuncType) Children() []Node {
+	var children []Node
+	for _, param := range n.Params {
+		children = append(children, param)
+	}
+	for _, input := range n.Inputs {
+		children = append(children, input)
+	}
+	children = append(children, n.Returns...)
+
+	return children
+}
+
+func (n *FuncType) Pos() lex.Token {
+	return n.Token
+}
+
+type Func struct {
+	Type  *FuncType
+	Body  *Block
+	Token lex.Token
+}
+
+func (n *Func) Children() []Node {
+	return []Node{n.Type, n.Body}
+}
+
+func (n *Func) Pos() lex.Token {
+	return n.Token
+}
+
+type Block struct {
+	Stmts []Node
+	Token lex.Token
+}
+
+func (n *Block) Children() []Node {
+	return n.Stmts
+}
+
+func (n *Block) Pos() lex.Token {
+	return n.Token
+}
+
+type Return struct {
+	Values []Node
+	Token  lex.Token
+}
+
+func (n *Return) Children() []Node {
+	return n.Values
+}
+
+func (n *Return) Pos() lex.Token {
+	return n.Token
+}
+
+type Call struct {
+	Fn    Node
+	Args  []Node
+	Token lex.Token
+}
+
+func (n *Call) Children() []Node {
+	return append([]Node{n.Fn}, n.Args...)
+}
+
+func (n *Call) Pos() lex.Token {
+	return n.Token
+}
+
+// parseSignature parses a function signature
+//
+//	(params)|inputs|<returns>
+//
+// the inputs and returns are optional
+func (p *parser) parseSignature() (*FuncType, error) {
+	open, err := p.expect(lex.OpenParen)
+	if err != nil {
+		return nil, err
+	}
+
+	fn := &FuncType{Token: open}
+	fn.Params, err = p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.parseSignatureEnd(fn); err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+// parseFnType parses a function type, the parameters are only listed by type
+//
+//	fn(types)|inputs|<returns>
+func (p *parser) parseFnType() (*FuncType, error) {
+	tok, err := p.expect(lex.Identifyer)
+	if err != nil {
+		return nil, err
+	}
+	if tok.Value != "fn" {
+		return nil, syntaxError(tok, "expected a function type")
+	}
+
+	if _, err := p.expect(lex.OpenParen); err != nil {
+		return nil, err
+	}
+
+	fn := &FuncType{Token: tok}
+	for !p.is(lex.CloseParen) {
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		fn.Params = append(fn.Params, &Var{Type: typ, Token: typ.Pos()})
+
+		if _, ok := p.accept(lex.Comma); !ok {
+			break
+		}
+	}
+
+	if _, err := p.expect(lex.CloseParen); err != nil {
+		return nil, err
+	}
+
+	if err := p.parseSignatureEnd(fn); err != nil {
+		return nil, err
+	}
+
+	return fn, nil
+}
+
+// parseSignatureEnd parses the optional inputs and returns that follow the parameters of a signature
+func (p *parser) parseSignatureEnd(fn *FuncType) error {
+	switch {
+	case p.is(lex.Or):
+		// || is an empty list of inputs
+		p.next()
+	case p.is(lex.Pipe):
+		p.next()
+		for !p.is(lex.Pipe) {
+			input, err := p.expect(lex.Identifyer)
+			if err != nil {
+				return err
+			}
+			fn.Inputs = append(fn.Inputs, &Ident{Name: input.Value, Token: input})
+
+			if _, ok := p.accept(lex.Comma); !ok {
+				break
+			}
+		}
+
+		if _, err := p.expect(lex.Pipe); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := p.accept(lex.LessThan); ok {
+		for !p.is(lex.GreaterThan) {
+			ret, err := p.parseType()
+			if err != nil {
+				return err
+			}
+			fn.Returns = append(fn.Returns, ret)
+
+			if _, ok := p.accept(lex.Comma); !ok {
+				break
+			}
+		}
+
+		if _, err := p.expect(lex.GreaterThan); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseParams parses the parameters of a function signature up to the closing paren
+// names that are followed by a comma share the type of the next parameter so (a, b:string)
+// declares two string parameters
+func (p *parser) parseParams() ([]*Var, error) {
+	var params []*Var
+	var pending []lex.Token
+
+	for !p.is(lex.CloseParen) {
+		name, err := p.expect(lex.Identifyer)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := p.accept(lex.Comma); ok {
+			pending = append(pending, name)
+			continue
+		}
+
+		p.accept(lex.Colon)
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tok := range append(pending, name) {
+			params = append(params, &Var{Name: tok.Value, Type: typ, Token: tok})
+		}
+		pending = nil
+
+		if _, ok := p.accept(lex.Comma); !ok {
+			break
+		}
+	}
+
+	if len(pending) > 0 {
+		return nil, syntaxError(pending[0], "missing parameter type")
+	}
+
+	if _, err := p.expect(lex.CloseParen); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// parseFunc parses a function literal, a signature followed by a body
+func (p *parser) parseFunc() (*Func, error) {
+	sig, err := p.parseSignature()
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Func{Type: sig, Body: body, Token: sig.Token}, nil
+}
+
+// isFunc returns true if the open paren at the current token starts a function literal
+func (p *parser) isFunc() bool {
+	// a grouped expression never starts with a parameter name followed by a type
+	switch p.peek(1).T {
+	case lex.CloseParen:
+	case lex.Identifyer:
+		switch p.peek(2).T {
+		case lex.Colon, lex.Comma, lex.CloseParen, lex.OpenParen, lex.Identifyer,
+			lex.StringType, lex.IntType, lex.BoolType, lex.CmdType:
+		case lex.OpenSquare:
+			// an array type rather than an index
+			if p.peek(3).T != lex.CloseSquare {
+				return false
+			}
+		default:
+			return false
+		}
+	default:
+		return false
+	}
+
+	depth := 0
+	for i := 0; p.pos+i < len(p.tokens); i++ {
+		switch p.peek(i).T {
+		case lex.OpenParen:
+			depth++
+		case lex.CloseParen:
+			depth--
+			if depth == 0 {
+				next := p.peek(i + 1).T
+				return next == lex.Pipe || next == lex.Or || next == lex.LessThan || next == lex.OpenBrace
+			}
+		}
+	}
+
+	return false
+}
+
+// parseReturn parses a return statement
+func (p *parser) parseReturn() (*Return, error) {
+	tok, err := p.expect(lex.ReturnKeyword)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &Return{Token: tok}
+	if p.is(lex.SemiColon, lex.CloseBrace) {
+		return ret, nil
+	}
+
+	ret.Values, err = p.parseExprList()
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
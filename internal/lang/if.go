@@ -0,0 +1,64 @@
+package lang
+
+import (
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+type If struct {
+	Cond Node
+	Then *Block
+
+	// Else is either an *If for an else if chain or a *Block
+	Else  Node
+	Token lex.Token
+}
+
+func (n *If) Children() []Node {
+	return []Node{n.Cond, n.Then, n.Else}
+}
+
+func (n *If) Pos() lex.Token {
+	return n.Token
+}
+
+// parseIf parses an if statement with any number of else if branches and an optional else
+//
+//	if cond { ... } else if cond { ... } else { ... }
+func (p *parser) parseIf() (*If, error) {
+	tok, err := p.expect(lex.IfKeyword)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	then, err := p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &If{Cond: cond, Then: then, Token: tok}
+
+	// else can start the line after the closing brace
+	if p.is(lex.SemiColon) && p.peek(1).T == lex.ElseKeyword {
+		p.next()
+	}
+
+	if _, ok := p.accept(lex.ElseKeyword); !ok {
+		return n, nil
+	}
+
+	if p.is(lex.IfKeyword) {
+		n.Else, err = p.parseIf()
+	} else {
+		n.Else, err = p.parseBlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return n, nil
+}
@@ -0,0 +1,67 @@
+package lang
+
+import (
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+type Loop struct {
+	Init  Node
+	Cond  Node
+	Post  Node
+	Body  *Block
+	Token lex.Token
+}
+
+func (n *Loop) Children() []Node {
+	return []Node{n.Init, n.Cond, n.Post, n.Body}
+}
+
+func (n *Loop) Pos() lex.Token {
+	return n.Token
+}
+
+// parseLoop parses a loop, the clauses before the body are separated by commas
+//
+//	loop { ... }
+//	loop cond { ... }
+//	loop cond, post { ... }
+//	loop init, cond, post { ... }
+func (p *parser) parseLoop() (*Loop, error) {
+	tok, err := p.expect(lex.LoopKeyword)
+	if err != nil {
+		return nil, err
+	}
+
+	var clauses []Node
+	for !p.is(lex.OpenBrace) {
+		clause, err := p.parseSimpleStmt()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, clause)
+
+		if _, ok := p.accept(lex.Comma); !ok {
+			break
+		}
+	}
+
+	loop := &Loop{Token: tok}
+	switch len(clauses) {
+	case 0:
+	case 1:
+		loop.Cond = clauses[0]
+	case 2:
+		loop.Cond, loop.Post = clauses[0], clauses[1]
+	case 3:
+		loop.Init, loop.Cond, loop.Post = clauses[0], clauses[1], clauses[2]
+	default:
+		return nil, syntaxError(tok, "too many loop clauses")
+	}
+
+	loop.Body, err = p.parseBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	return loop, nil
+}
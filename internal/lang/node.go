@@ -8,6 +8,9 @@ import (
 
 type Node interface {
 	Children() []Node
+
+	// Pos returns the token the node starts at
+	Pos() lex.Token
 }
 
 type Root struct {
@@ -25,16 +28,26 @@ func (n *Root) Children() []Node {
 	return children
 }
 
+// Pos returns an empty token since the root is not written in the source
+func (n *Root) Pos() lex.Token {
+	return lex.Token{}
+}
+
 type Import struct {
-	Name string
-	As   string
-	From string
+	Name  string
+	As    string
+	From  string
+	Token lex.Token
 }
 
 func (n *Import) Children() []Node {
 	return nil
 }
 
+func (n *Import) Pos() lex.Token {
+	return n.Token
+}
+
 func MatchImport(tokens []lex.Token) bool {
 	return len(tokens) > 0 && tokens[0].T == lex.ImportKeyword
 }
@@ -46,7 +59,8 @@ func NewImport(tokens []lex.Token) (Node, error) {
 			tokens[1].T == lex.Identifyer &&
 			tokens[2].T == lex.SemiColon {
 			return &Import{
-				Name: tokens[1].Value,
+				Name:  tokens[1].Value,
+				Token: tokens[0],
 			}, nil
 		}
 	case 5:
@@ -56,8 +70,9 @@ func NewImport(tokens []lex.Token) (Node, error) {
 			tokens[3].T == lex.Identifyer &&
 			tokens[4].T == lex.SemiColon {
 			return &Import{
-				Name: tokens[1].Value,
-				As:   tokens[3].Value,
+				Name:  tokens[1].Value,
+				As:    tokens[3].Value,
+				Token: tokens[0],
 			}, nil
 		}
 
@@ -67,8 +82,9 @@ func NewImport(tokens []lex.Token) (Node, error) {
 			tokens[3].T == lex.String &&
 			tokens[4].T == lex.SemiColon {
 			return &Import{
-				Name: tokens[1].Value,
-				From: tokens[3].Value,
+				Name:  tokens[1].Value,
+				From:  tokens[3].Value,
+				Token: tokens[0],
 			}, nil
 		}
 	case 7:
@@ -80,16 +96,25 @@ func NewImport(tokens []lex.Token) (Node, error) {
 			tokens[5].T == lex.String &&
 			tokens[6].T == lex.SemiColon {
 			return &Import{
-				Name: tokens[1].Value,
-				As:   tokens[3].Value,
-				From: tokens[5].Value,
+				Name:  tokens[1].Value,
+				As:    tokens[3].Value,
+				From:  tokens[5].Value,
+				Token: tokens[0],
 			}, nil
 		}
 	}
 
+	tok := lex.Token{}
+	if len(tokens) > 0 {
+		tok = tokens[0]
+	}
 	return nil, errors.New(
 		bear.WithErrType(errors.SyntaxError),
+		bear.WithExitCode(errors.ASTFailed),
 		bear.WithLabels("invalid import"),
+		errors.WithCode(errors.InvalidImportCode),
+		errors.WithHint(`imports are written as import name, import name as alias or import name from "file.bk"`),
+		errors.At(tok.FileName, tok.LineNumber, tok.ColNumber, tok.Value),
 	)
 }
 
@@ -97,32 +122,182 @@ type Var struct {
 	Name    string
 	Type    Node
 	Default Node
+	Token   lex.Token
 }
 
 func MatchVar(tokens []lex.Token) bool {
-	return false
+	return newParser(tokens).isDecl()
 }
 
 func NewVar(tokens []lex.Token) (Node, error) {
-	return nil, nil
+	p := newParser(tokens)
+	v, err := p.parseDecl()
+	if err == nil {
+		err = p.expectEnd()
+	}
+
+	// statements that failed to parse inside of function bodies are reported along with the declaration's own error
+	p.diags.Add(err)
+	if err := p.diags.Err(); err != nil {
+		return nil, err
+	}
+
+	return v, nil
 }
 
 func (n *Var) Children() []Node {
 	return []Node{n.Type, n.Default}
 }
 
+func (n *Var) Pos() lex.Token {
+	return n.Token
+}
+
+type Destructure struct {
+	Names []*Ident
+	Value Node
+	Token lex.Token
+}
+
+func (n *Destructure) Children() []Node {
+	var children []Node
+	for _, name := range n.Names {
+		children = append(children, name)
+	}
+
+	return append(children, n.Value)
+}
+
+func (n *Destructure) Pos() lex.Token {
+	return n.Token
+}
+
+type Assign struct {
+	Target Node
+	Value  Node
+	Token  lex.Token
+}
+
+func (n *Assign) Children() []Node {
+	return []Node{n.Target, n.Value}
+}
+
+func (n *Assign) Pos() lex.Token {
+	return n.Token
+}
+
+type TypeName struct {
+	Name string
+
+	// Source is the name written in the source file, it is only set if the name was changed when the file was loaded
+	Source string
+	Token  lex.Token
+}
+
+func (n *TypeName) Children() []Node {
+	return nil
+}
+
+func (n *TypeName) Pos() lex.Token {
+	return n.Token
+}
+
+// SourceName returns the name the way it is written in the source file so it can be used in messages
+func (n *TypeName) SourceName() string {
+	if n.Source != "" {
+		return n.Source
+	}
+
+	return n.Name
+}
+
+type ArrayType struct {
+	Elem  Node
+	Token lex.Token
+}
+
+func (n *ArrayType) Children() []Node {
+	return []Node{n.Elem}
+}
+
+func (n *ArrayType) Pos() lex.Token {
+	return n.Token
+}
+
+type Ident struct {
+	Name string
+
+	// Source is the name written in the source file, it is only set if the name was changed when the file was loaded
+	Source string
+	Token  lex.Token
+}
+
+func (n *Ident) Children() []Node {
+	return nil
+}
+
+func (n *Ident) Pos() lex.Token {
+	return n.Token
+}
+
+// SourceName returns the name the way it is written in the source file so it can be used in messages
+func (n *Ident) SourceName() string {
+	if n.Source != "" {
+		return n.Source
+	}
+
+	return n.Name
+}
+
 type Exec struct {
-	Expr Node
+	Expr  Node
+	Token lex.Token
+}
+
+func (n *Exec) Children() []Node {
+	return []Node{n.Expr}
+}
+
+func (n *Exec) Pos() lex.Token {
+	return n.Token
+}
+
+type Cmd struct {
+	Name  *Ident
+	Args  []Node
+	Token lex.Token
+}
+
+func (n *Cmd) Children() []Node {
+	return append([]Node{n.Name}, n.Args...)
+}
+
+func (n *Cmd) Pos() lex.Token {
+	return n.Token
 }
 
 type Comment struct {
 	Value string
 }
 
+func (n *Comment) Children() []Node {
+	return nil
+}
+
+// Pos returns an empty token since comments do not keep their position
+func (n *Comment) Pos() lex.Token {
+	return lex.Token{}
+}
+
 type String struct {
-	Value Node
+	Value string
+	Token lex.Token
 }
 
-type StringArray struct {
-	Value Node
+func (n *String) Children() []Node {
+	return nil
+}
+
+func (n *String) Pos() lex.Token {
+	return n.Token
 }
@@ -0,0 +1,674 @@
+package lang
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+func TestNewVar(t *testing.T) {
+	type args struct {
+		tokens []lex.Token
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Node
+		wantErr bool
+	}{
+		{
+			"typed without default",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name:  "a",
+				Type:  &TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}},
+				Token: lex.Token{T: lex.Identifyer, Value: "a"},
+			},
+			false,
+		},
+		{
+			"typed with default",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "b"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "b",
+				Type: &ArrayType{
+					Elem:  &TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Default: &Ident{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+				Token:   lex.Token{T: lex.Identifyer, Value: "b"},
+			},
+			false,
+		},
+		{
+			"int array type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "i"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.IntType, Value: "int"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "i",
+				Type: &ArrayType{
+					Elem:  &TypeName{Name: "int", Token: lex.Token{T: lex.IntType, Value: "int"}},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "i"},
+			},
+			false,
+		},
+		{
+			"nested array type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "d"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "d",
+				Type: &ArrayType{
+					Elem: &ArrayType{
+						Elem:  &TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}},
+						Token: lex.Token{T: lex.OpenSquare, Value: "["},
+					},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "d"},
+			},
+			false,
+		},
+		{
+			"fn type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "f"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "fn"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.IntType, Value: "int"},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "f",
+				Type: &FuncType{
+					Params: []*Var{
+						{
+							Type:  &TypeName{Name: "int", Token: lex.Token{T: lex.IntType, Value: "int"}},
+							Token: lex.Token{T: lex.IntType, Value: "int"},
+						},
+					},
+					Returns: []Node{&TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}}},
+					Token:   lex.Token{T: lex.Identifyer, Value: "fn"},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "f"},
+			},
+			false,
+		},
+		{
+			"bool with default",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "ok"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.BoolType, Value: "bool"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Bool, Value: "true"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name:    "ok",
+				Type:    &TypeName{Name: "bool", Token: lex.Token{T: lex.BoolType, Value: "bool"}},
+				Default: &Bool{Value: true, Token: lex.Token{T: lex.Bool, Value: "true"}},
+				Token:   lex.Token{T: lex.Identifyer, Value: "ok"},
+			},
+			false,
+		},
+		{
+			"type inference",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "c"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.String, Value: "hi"},
+					{T: lex.Comment, Value: " say hi"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name:    "c",
+				Default: &String{Value: "hi", Token: lex.Token{T: lex.String, Value: "hi"}},
+				Token:   lex.Token{T: lex.Identifyer, Value: "c"},
+			},
+			false,
+		},
+		{
+			"function",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "f"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "b"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.Pipe, Value: "|"},
+					{T: lex.Identifyer, Value: "in"},
+					{T: lex.Pipe, Value: "|"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.ReturnKeyword, Value: "return"},
+					{T: lex.Identifyer, Value: "in"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.CloseBrace, Value: "}"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			func() Node {
+				str := &TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}}
+				sig := &FuncType{
+					Params: []*Var{
+						{Name: "a", Type: str, Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+						{Name: "b", Type: str, Token: lex.Token{T: lex.Identifyer, Value: "b"}},
+					},
+					Inputs:  []*Ident{{Name: "in", Token: lex.Token{T: lex.Identifyer, Value: "in"}}},
+					Returns: []Node{str},
+					Token:   lex.Token{T: lex.OpenParen, Value: "("},
+				}
+				return &Var{
+					Name: "f",
+					Type: sig,
+					Default: &Func{
+						Type: sig,
+						Body: &Block{
+							Stmts: []Node{
+								&Return{
+									Values: []Node{&Ident{Name: "in", Token: lex.Token{T: lex.Identifyer, Value: "in"}}},
+									Token:  lex.Token{T: lex.ReturnKeyword, Value: "return"},
+								},
+							},
+							Token: lex.Token{T: lex.OpenBrace, Value: "{"},
+						},
+						Token: lex.Token{T: lex.OpenParen, Value: "("},
+					},
+					Token: lex.Token{T: lex.Identifyer, Value: "f"},
+				}
+			}(),
+			false,
+		},
+		{
+			"destructure",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "b"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.Identifyer, Value: "f"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Destructure{
+				Names: []*Ident{
+					{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+					{Name: "b", Token: lex.Token{T: lex.Identifyer, Value: "b"}},
+				},
+				Value: &Call{
+					Fn:    &Ident{Name: "f", Token: lex.Token{T: lex.Identifyer, Value: "f"}},
+					Token: lex.Token{T: lex.OpenParen, Value: "("},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "a"},
+			},
+			false,
+		},
+		{
+			"missing param type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "f"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			nil,
+			true,
+		},
+		{
+			"struct type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.Identifyer, Value: "x"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "y"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.Identifyer, Value: "show"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.CloseBrace, Value: "}"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			func() Node {
+				str := &TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}}
+				sig := &FuncType{Token: lex.Token{T: lex.OpenParen, Value: "("}}
+				return &Var{
+					Name: "v2",
+					Default: &StructType{
+						Fields: []*Var{
+							{Name: "x", Type: str, Token: lex.Token{T: lex.Identifyer, Value: "x"}},
+							{Name: "y", Type: str, Token: lex.Token{T: lex.Identifyer, Value: "y"}},
+						},
+						Methods: []*Var{
+							{
+								Name: "show",
+								Type: sig,
+								Default: &Func{
+									Type:  sig,
+									Body:  &Block{Token: lex.Token{T: lex.OpenBrace, Value: "{"}},
+									Token: lex.Token{T: lex.OpenParen, Value: "("},
+								},
+								Token: lex.Token{T: lex.Identifyer, Value: "show"},
+							},
+						},
+						Token: lex.Token{T: lex.LessThan, Value: "<"},
+					},
+					Token: lex.Token{T: lex.Identifyer, Value: "v2"},
+				}
+			}(),
+			false,
+		},
+		{
+			"struct field defaults",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "v4"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.Identifyer, Value: "x"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "y"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "z"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "w"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.IntType, Value: "int"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Number, Value: "1"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			func() Node {
+				num := &TypeName{Name: "int", Token: lex.Token{T: lex.IntType, Value: "int"}}
+				one := &Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}}
+				return &Var{
+					Name: "v4",
+					Default: &StructType{
+						Fields: []*Var{
+							{Name: "x", Type: num, Default: one, Token: lex.Token{T: lex.Identifyer, Value: "x"}},
+							{Name: "y", Type: num, Default: one, Token: lex.Token{T: lex.Identifyer, Value: "y"}},
+							{Name: "z", Type: num, Default: one, Token: lex.Token{T: lex.Identifyer, Value: "z"}},
+							{Name: "w", Type: num, Default: one, Token: lex.Token{T: lex.Identifyer, Value: "w"}},
+						},
+						Token: lex.Token{T: lex.LessThan, Value: "<"},
+					},
+					Token: lex.Token{T: lex.Identifyer, Value: "v4"},
+				}
+			}(),
+			false,
+		},
+		{
+			"struct field default comparison",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "opts"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.Identifyer, Value: "big"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.BoolType, Value: "bool"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.Number, Value: "2"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.Number, Value: "1"},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "opts",
+				Default: &StructType{
+					Fields: []*Var{
+						{
+							Name: "big",
+							Type: &TypeName{Name: "bool", Token: lex.Token{T: lex.BoolType, Value: "bool"}},
+							Default: &Binary{
+								Op:    ">",
+								X:     &Int{Value: "2", Token: lex.Token{T: lex.Number, Value: "2"}},
+								Y:     &Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}},
+								Token: lex.Token{T: lex.GreaterThan, Value: ">"},
+							},
+							Token: lex.Token{T: lex.Identifyer, Value: "big"},
+						},
+					},
+					Token: lex.Token{T: lex.LessThan, Value: "<"},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "opts"},
+			},
+			false,
+		},
+		{
+			"nested struct fields",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "square"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.Identifyer, Value: "one"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "two"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			func() Node {
+				v2 := &TypeName{Name: "v2", Token: lex.Token{T: lex.Identifyer, Value: "v2"}}
+				return &Var{
+					Name: "square",
+					Default: &StructType{
+						Fields: []*Var{
+							{Name: "one", Type: v2, Token: lex.Token{T: lex.Identifyer, Value: "one"}},
+							{Name: "two", Type: v2, Token: lex.Token{T: lex.Identifyer, Value: "two"}},
+						},
+						Token: lex.Token{T: lex.LessThan, Value: "<"},
+					},
+					Token: lex.Token{T: lex.Identifyer, Value: "square"},
+				}
+			}(),
+			false,
+		},
+		{
+			"struct array fields",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "greet"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.LessThan, Value: "<"},
+					{T: lex.Identifyer, Value: "lang"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "greet"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.StringType, Value: "string"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "greet",
+				Default: &StructType{
+					Fields: []*Var{
+						{
+							Name:  "lang",
+							Type:  &TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}},
+							Token: lex.Token{T: lex.Identifyer, Value: "lang"},
+						},
+						{
+							Name: "greet",
+							Type: &ArrayType{
+								Elem:  &TypeName{Name: "string", Token: lex.Token{T: lex.StringType, Value: "string"}},
+								Token: lex.Token{T: lex.OpenSquare, Value: "["},
+							},
+							Token: lex.Token{T: lex.Identifyer, Value: "greet"},
+						},
+					},
+					Token: lex.Token{T: lex.LessThan, Value: "<"},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "greet"},
+			},
+			false,
+		},
+		{
+			"struct array type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "asa"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "asa",
+				Type: &ArrayType{
+					Elem:  &TypeName{Name: "v2", Token: lex.Token{T: lex.Identifyer, Value: "v2"}},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "asa"},
+			},
+			false,
+		},
+		{
+			"nested fn array type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "fs"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.Identifyer, Value: "fn"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.CmdType, Value: "cmd"},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "fs",
+				Type: &ArrayType{
+					Elem: &ArrayType{
+						Elem: &FuncType{
+							Params: []*Var{
+								{Type: &TypeName{Name: "cmd", Token: lex.Token{T: lex.CmdType, Value: "cmd"}}, Token: lex.Token{T: lex.CmdType, Value: "cmd"}},
+							},
+							Token: lex.Token{T: lex.Identifyer, Value: "fn"},
+						},
+						Token: lex.Token{T: lex.OpenSquare, Value: "["},
+					},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "fs"},
+			},
+			false,
+		},
+		{
+			"array length",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "n"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.Identifyer, Value: "len"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.Minus, Value: "-"},
+					{T: lex.Number, Value: "1"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "n",
+				Default: &Binary{
+					Op: "-",
+					X: &Len{
+						X:     &Ident{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+						Token: lex.Token{T: lex.Identifyer, Value: "len"},
+					},
+					Y:     &Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}},
+					Token: lex.Token{T: lex.Minus, Value: "-"},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "n"},
+			},
+			false,
+		},
+		{
+			"struct literal",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "v2"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.Identifyer, Value: "x"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "b"},
+					{T: lex.Dot, Value: "."},
+					{T: lex.Identifyer, Value: "y"},
+					{T: lex.CloseBrace, Value: "}"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			&Var{
+				Name: "a",
+				Type: &TypeName{Name: "v2", Token: lex.Token{T: lex.Identifyer, Value: "v2"}},
+				Default: &StructLit{
+					Fields: []*KeyValue{
+						{
+							Key: &Ident{Name: "x", Token: lex.Token{T: lex.Identifyer, Value: "x"}},
+							Value: &Field{
+								X:     &Ident{Name: "b", Token: lex.Token{T: lex.Identifyer, Value: "b"}},
+								Name:  &Ident{Name: "y", Token: lex.Token{T: lex.Identifyer, Value: "y"}},
+								Token: lex.Token{T: lex.Dot, Value: "."},
+							},
+							Token: lex.Token{T: lex.Identifyer, Value: "x"},
+						},
+					},
+					Token: lex.Token{T: lex.OpenBrace, Value: "{"},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "a"},
+			},
+			false,
+		},
+		{
+			"missing type",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "d"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			nil,
+			true,
+		},
+		{
+			"trailing tokens",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "e"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.String, Value: "hi"},
+					{T: lex.String, Value: "there"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			nil,
+			true,
+		},
+		{
+			"separate colons",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "f"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.String, Value: "hi"},
+					{T: lex.SemiColon, Value: ";"},
+				},
+			},
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewVar(tt.args.tokens)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewVar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewVar() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,599 @@
+package lang
+
+import (
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+// parser is a recursive descent parser for a single expression block
+type parser struct {
+	tokens []lex.Token
+	pos    int
+
+	// diags collects the errors of statements that failed to parse so the rest of the block can still be parsed
+	diags *errors.Collector
+
+	// fieldDefault is true while the default value of a struct field is parsed,
+	// a > outside of any brackets closes the struct type instead of comparing values
+	fieldDefault bool
+}
+
+// newParser creates a parser for the tokens, comments are dropped since they do not affect the AST
+func newParser(tokens []lex.Token) *parser {
+	var filtered []lex.Token
+	for _, tok := range tokens {
+		if tok.T != lex.Comment {
+			filtered = append(filtered, tok)
+		}
+	}
+
+	return &parser{tokens: filtered, diags: errors.NewCollector(0)}
+}
+
+// peek returns the token n tokens past the current token without consuming it
+// a SemiColon is returned once the end of the tokens has been reached
+func (p *parser) peek(n int) lex.Token {
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
+	}
+
+	end := lex.Token{T: lex.SemiColon, Value: ";"}
+	if len(p.tokens) > 0 {
+		last := p.tokens[len(p.tokens)-1]
+		end.FileName = last.FileName
+		end.LineNumber = last.LineNumber
+		end.ColNumber = last.ColNumber + len(last.Value)
+	}
+
+	return end
+}
+
+// next consumes and returns the current token
+func (p *parser) next() lex.Token {
+	tok := p.peek(0)
+	p.pos++
+
+	return tok
+}
+
+// is returns true if the current token is any of the given types
+func (p *parser) is(types ...lex.TokType) bool {
+	tok := p.peek(0)
+	for _, t := range types {
+		if tok.T == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// accept consumes the current token if it is any of the given types
+func (p *parser) accept(types ...lex.TokType) (lex.Token, bool) {
+	if !p.is(types...) {
+		return lex.Token{}, false
+	}
+
+	return p.next(), true
+}
+
+// group parses > as a comparison again until the returned function is called,
+// brackets end the struct type so a > inside of them can not close it
+func (p *parser) group() func() {
+	saved := p.fieldDefault
+	p.fieldDefault = false
+
+	return func() { p.fieldDefault = saved }
+}
+
+// skip consumes tokens as long as they are any of the given types
+func (p *parser) skip(types ...lex.TokType) {
+	for p.pos < len(p.tokens) && p.is(types...) {
+		p.next()
+	}
+}
+
+// expect consumes the current token and returns an error if it is not of the given type
+func (p *parser) expect(t lex.TokType) (lex.Token, error) {
+	tok := p.next()
+	if tok.T != t {
+		return tok, syntaxError(tok, "expected "+t.String())
+	}
+
+	return tok, nil
+}
+
+// expectEnd returns an error if there are any tokens left other than the closing SemiColon
+func (p *parser) expectEnd() error {
+	p.accept(lex.SemiColon)
+	if p.pos < len(p.tokens) {
+		return syntaxError(p.peek(0), "unexpected "+p.peek(0).T.String())
+	}
+
+	return nil
+}
+
+// parseDecl parses a variable declaration or a destructuring declaration
+//
+//	a, b :: value
+func (p *parser) parseDecl() (Node, error) {
+	if p.peek(1).T != lex.Comma {
+		return p.parseVar()
+	}
+
+	d := &Destructure{Token: p.peek(0)}
+	for {
+		name, err := p.expect(lex.Identifyer)
+		if err != nil {
+			return nil, err
+		}
+		d.Names = append(d.Names, &Ident{Name: name.Value, Token: name})
+
+		if _, ok := p.accept(lex.Comma); !ok {
+			break
+		}
+	}
+
+	if _, err := p.expect(lex.DoubleColon); err != nil {
+		return nil, err
+	}
+
+	var err error
+	d.Value, err = p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// parseVar parses a variable declaration
+//
+//	name:type
+//	name:type: value
+//	name :: value
+//
+// functions are declared with a signature as the type and a block as the value
+//
+//	name:(params): { ... }
+func (p *parser) parseVar() (*Var, error) {
+	name, err := p.expect(lex.Identifyer)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Var{Name: name.Value, Token: name}
+
+	// name :: value infers the type from the value
+	if _, ok := p.accept(lex.DoubleColon); ok {
+		v.Default, err = p.parseExpr()
+		return v, err
+	}
+
+	if _, err := p.expect(lex.Colon); err != nil {
+		return nil, err
+	}
+
+	v.Type, err = p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := p.accept(lex.Colon); !ok {
+		return v, nil
+	}
+
+	v.Default, err = p.parseDefault(v.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// parseDefault parses the default value of a declaration with the given type
+// a function type followed by a block declares a function
+func (p *parser) parseDefault(typ Node) (Node, error) {
+	if sig, ok := typ.(*FuncType); ok && p.is(lex.OpenBrace) {
+		for _, param := range sig.Params {
+			if param.Name == "" {
+				return nil, syntaxError(param.Token, "function parameters must be named")
+			}
+		}
+
+		body, err := p.parseBlock()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Func{Type: sig, Body: body, Token: sig.Token}, nil
+	}
+
+	// a cmd without any arguments would otherwise be read as an index without an index
+	if name, ok := typ.(*TypeName); ok && name.Name == "cmd" &&
+		p.is(lex.Identifyer) && p.peek(1).T == lex.OpenSquare && p.peek(2).T == lex.CloseSquare {
+		return p.parseCmd()
+	}
+
+	return p.parseExpr()
+}
+
+// isDecl returns true if the statement at the current token is a declaration rather than an assignment
+// the ambiguous name:ident form is treated as a declaration
+func (p *parser) isDecl() bool {
+	i := 0
+	for p.peek(i).T == lex.Identifyer && p.peek(i+1).T == lex.Comma {
+		i += 2
+	}
+
+	if p.peek(i).T != lex.Identifyer {
+		return false
+	}
+
+	if p.peek(i+1).T == lex.DoubleColon {
+		return true
+	}
+
+	if i > 0 || p.peek(i+1).T != lex.Colon {
+		return false
+	}
+
+	// speculatively parse a type and check that it is followed by the end of the declaration
+	start := p.pos
+	defer func() { p.pos = start }()
+
+	p.pos += 2
+	if _, err := p.parseType(); err != nil {
+		return false
+	}
+
+	return p.is(lex.Colon, lex.SemiColon, lex.CloseBrace)
+}
+
+// parseType parses a type
+func (p *parser) parseType() (Node, error) {
+	switch {
+	case p.is(lex.OpenParen):
+		return p.parseSignature()
+	case p.is(lex.LessThan):
+		return p.parseStructType()
+	case p.is(lex.Identifyer) && p.peek(0).Value == "fn" && p.peek(1).T == lex.OpenParen:
+		return p.parseFnType()
+	case p.is(lex.OpenSquare) && p.peek(1).T == lex.CloseSquare:
+		// every leading [] adds a level of nesting to the element type that follows
+		open := p.next()
+		p.next()
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		return &ArrayType{Elem: elem, Token: open}, nil
+	}
+
+	tok := p.next()
+	switch tok.T {
+	case lex.StringType, lex.IntType, lex.BoolType, lex.CmdType:
+		return &TypeName{Name: tok.Value, Token: tok}, nil
+	case lex.Identifyer:
+		return &TypeName{Name: tok.Value, Token: tok}, nil
+	default:
+		return nil, syntaxError(tok, "expected a type")
+	}
+}
+
+// parseBlock parses a list of statements surrounded by braces
+func (p *parser) parseBlock() (*Block, error) {
+	defer p.group()()
+
+	open, err := p.expect(lex.OpenBrace)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &Block{Token: open}
+	for {
+		p.skip(lex.SemiColon)
+
+		if _, ok := p.accept(lex.CloseBrace); ok {
+			return block, nil
+		}
+
+		if p.pos >= len(p.tokens) {
+			return nil, syntaxError(p.peek(0), "expected "+lex.CloseBrace.String())
+		}
+
+		stmt, err := p.parseStmt()
+		if err == nil && !p.is(lex.SemiColon, lex.CloseBrace) {
+			err = syntaxError(p.peek(0), "unexpected "+p.peek(0).T.String())
+		}
+		if err != nil {
+			p.diags.Add(err)
+			p.sync()
+			continue
+		}
+		block.Stmts = append(block.Stmts, stmt)
+	}
+}
+
+// sync skips the rest of a statement that failed to parse, it stops at the SemiColon that ends the statement
+// or at the brace that closes the block, any brackets opened after the error are skipped along with their contents
+func (p *parser) sync() {
+	depth := 0
+	for p.pos < len(p.tokens) {
+		switch p.peek(0).T {
+		case lex.OpenParen, lex.OpenBrace, lex.OpenSquare:
+			depth++
+		case lex.CloseParen, lex.CloseSquare:
+			if depth > 0 {
+				depth--
+			}
+		case lex.CloseBrace:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case lex.SemiColon:
+			if depth == 0 {
+				return
+			}
+		}
+		p.next()
+	}
+}
+
+// parseStmt parses a single statement inside of a block
+func (p *parser) parseStmt() (Node, error) {
+	switch {
+	case p.is(lex.ReturnKeyword):
+		return p.parseReturn()
+	case p.is(lex.LoopKeyword):
+		return p.parseLoop()
+	case p.is(lex.IfKeyword):
+		return p.parseIf()
+	}
+
+	stmt, err := p.parseSimpleStmt()
+	if err != nil {
+		return nil, err
+	}
+
+	switch stmt.(type) {
+	case *Var, *Destructure, *Assign, *IncDec, *Append, *Pop, *Call, *Exec, *Pipeline:
+		return stmt, nil
+	default:
+		return nil, syntaxError(stmt.Pos(), "expected a statement")
+	}
+}
+
+// parseSimpleStmt parses a declaration, assignment, increment, append or expression
+func (p *parser) parseSimpleStmt() (Node, error) {
+	if p.isDecl() {
+		return p.parseDecl()
+	}
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if colon, ok := p.accept(lex.Colon); ok {
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Assign{Target: expr, Value: value, Token: colon}, nil
+	}
+
+	// a += b is an assignment of a + b
+	if op, ok := compoundOps[p.peek(0).T]; ok {
+		tok := p.next()
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Assign{Target: expr, Value: &Binary{Op: op, X: expr, Y: value, Token: tok}, Token: tok}, nil
+	}
+
+	if tok, ok := p.accept(lex.Increment, lex.Decrement); ok {
+		return &IncDec{Op: tok.Value, X: expr, Token: tok}, nil
+	}
+
+	if tok, ok := p.accept(lex.Append); ok {
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Append{X: expr, Value: value, Token: tok}, nil
+	}
+
+	return expr, nil
+}
+
+// parseExprList parses a comma separated list of expressions
+func (p *parser) parseExprList() ([]Node, error) {
+	var exprs []Node
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		if _, ok := p.accept(lex.Comma); !ok {
+			return exprs, nil
+		}
+	}
+}
+
+// parseArgs parses a comma separated list of expressions that ends with the closing token
+func (p *parser) parseArgs(close lex.TokType) ([]Node, error) {
+	defer p.group()()
+
+	var args []Node
+	for !p.is(close) {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		if _, ok := p.accept(lex.Comma); !ok {
+			break
+		}
+	}
+
+	if _, err := p.expect(close); err != nil {
+		return nil, err
+	}
+
+	return args, nil
+}
+
+// parseExpr parses an expression
+func (p *parser) parseExpr() (Node, error) {
+	return p.parsePipeline()
+}
+
+// parsePostfix parses an operand followed by any number of calls, field selectors, indexes and pops
+func (p *parser) parsePostfix() (Node, error) {
+	expr, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch {
+		case p.is(lex.OpenParen):
+			open := p.next()
+			args, err := p.parseArgs(lex.CloseParen)
+			if err != nil {
+				return nil, err
+			}
+			expr = &Call{Fn: expr, Args: args, Token: open}
+		case p.is(lex.Dot):
+			dot := p.next()
+			name, err := p.expect(lex.Identifyer)
+			if err != nil {
+				return nil, err
+			}
+			expr = &Field{X: expr, Name: &Ident{Name: name.Value, Token: name}, Token: dot}
+		case p.is(lex.OpenSquare):
+			expr, err = p.parseIndex(expr)
+			if err != nil {
+				return nil, err
+			}
+		case p.is(lex.Pop):
+			expr = &Pop{X: expr, Token: p.next()}
+		default:
+			return expr, nil
+		}
+	}
+}
+
+// parseOperand parses a single literal, identifier, function, struct or exec
+// len is a builtin so len( always starts the length of an array
+func (p *parser) parseOperand() (Node, error) {
+	switch {
+	case p.is(lex.Identifyer) && p.peek(0).Value == "len" && p.peek(1).T == lex.OpenParen:
+		return p.parseLen()
+	case p.is(lex.OpenParen) && p.isFunc():
+		return p.parseFunc()
+	case p.is(lex.OpenParen):
+		return p.parseParen()
+	case p.is(lex.Exec):
+		return p.parseExec()
+	case p.is(lex.LessThan):
+		return p.parseStructType()
+	case p.is(lex.OpenBrace):
+		return p.parseStructLit()
+	case p.is(lex.OpenSquare):
+		return p.parseArrayLit()
+	}
+
+	tok := p.next()
+	switch tok.T {
+	case lex.String:
+		return &String{Value: tok.Value, Token: tok}, nil
+	case lex.Number:
+		return &Int{Value: tok.Value, Token: tok}, nil
+	case lex.Bool:
+		return &Bool{Value: tok.Value == "true", Token: tok}, nil
+	case lex.Identifyer:
+		return &Ident{Name: tok.Value, Token: tok}, nil
+	default:
+		return nil, syntaxError(tok, "expected an expression")
+	}
+}
+
+// parseParen parses an expression grouped by parens
+func (p *parser) parseParen() (Node, error) {
+	defer p.group()()
+
+	if _, err := p.expect(lex.OpenParen); err != nil {
+		return nil, err
+	}
+
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.expect(lex.CloseParen); err != nil {
+		return nil, err
+	}
+
+	return x, nil
+}
+
+// parseExec parses a command execution
+//
+//	$name[args]
+func (p *parser) parseExec() (*Exec, error) {
+	tok, err := p.expect(lex.Exec)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := p.parseCmd()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exec{Expr: cmd, Token: tok}, nil
+}
+
+// parseCmd parses a command and its arguments
+//
+//	name[args]
+func (p *parser) parseCmd() (*Cmd, error) {
+	name, err := p.expect(lex.Identifyer)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.expect(lex.OpenSquare); err != nil {
+		return nil, err
+	}
+
+	args, err := p.parseArgs(lex.CloseSquare)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cmd{Name: &Ident{Name: name.Value, Token: name}, Args: args, Token: name}, nil
+}
+
+// syntaxError creates a new syntax error at the position of tok
+func syntaxError(tok lex.Token, msg string) error {
+	return errors.New(
+		bear.WithErrType(errors.SyntaxError),
+		bear.WithExitCode(errors.ASTFailed),
+		bear.WithLabels(msg),
+		errors.WithCode(errors.SyntaxCode),
+		errors.At(tok.FileName, tok.LineNumber, tok.ColNumber, tok.Value),
+	)
+}
@@ -0,0 +1,395 @@
+package lang
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+func Test_parser_parseStmt(t *testing.T) {
+	type args struct {
+		tokens []lex.Token
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Node
+		wantErr bool
+	}{
+		{
+			"three clause loop",
+			args{
+				tokens: []lex.Token{
+					{T: lex.LoopKeyword, Value: "loop"},
+					{T: lex.Identifyer, Value: "i"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.Number, Value: "0"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "i"},
+					{T: lex.LessEqual, Value: "<="},
+					{T: lex.Number, Value: "10"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "i"},
+					{T: lex.Increment, Value: "++"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.CloseBrace, Value: "}"},
+				},
+			},
+			&Loop{
+				Init: &Var{
+					Name:    "i",
+					Default: &Int{Value: "0", Token: lex.Token{T: lex.Number, Value: "0"}},
+					Token:   lex.Token{T: lex.Identifyer, Value: "i"},
+				},
+				Cond: &Binary{
+					Op:    "<=",
+					X:     &Ident{Name: "i", Token: lex.Token{T: lex.Identifyer, Value: "i"}},
+					Y:     &Int{Value: "10", Token: lex.Token{T: lex.Number, Value: "10"}},
+					Token: lex.Token{T: lex.LessEqual, Value: "<="},
+				},
+				Post: &IncDec{
+					Op:    "++",
+					X:     &Ident{Name: "i", Token: lex.Token{T: lex.Identifyer, Value: "i"}},
+					Token: lex.Token{T: lex.Increment, Value: "++"},
+				},
+				Body:  &Block{Token: lex.Token{T: lex.OpenBrace, Value: "{"}},
+				Token: lex.Token{T: lex.LoopKeyword, Value: "loop"},
+			},
+			false,
+		},
+		{
+			"condition loop",
+			args{
+				tokens: []lex.Token{
+					{T: lex.LoopKeyword, Value: "loop"},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.GreaterThan, Value: ">"},
+					{T: lex.Identifyer, Value: "b"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.CloseBrace, Value: "}"},
+				},
+			},
+			&Loop{
+				Cond: &Binary{
+					Op:    ">",
+					X:     &Ident{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+					Y:     &Ident{Name: "b", Token: lex.Token{T: lex.Identifyer, Value: "b"}},
+					Token: lex.Token{T: lex.GreaterThan, Value: ">"},
+				},
+				Body:  &Block{Token: lex.Token{T: lex.OpenBrace, Value: "{"}},
+				Token: lex.Token{T: lex.LoopKeyword, Value: "loop"},
+			},
+			false,
+		},
+		{
+			"if else if else",
+			args{
+				tokens: []lex.Token{
+					{T: lex.IfKeyword, Value: "if"},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Or, Value: "||"},
+					{T: lex.Bang, Value: "!"},
+					{T: lex.Identifyer, Value: "b"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.CloseBrace, Value: "}"},
+					{T: lex.ElseKeyword, Value: "else"},
+					{T: lex.IfKeyword, Value: "if"},
+					{T: lex.Identifyer, Value: "b"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.CloseBrace, Value: "}"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.ElseKeyword, Value: "else"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.CloseBrace, Value: "}"},
+				},
+			},
+			&If{
+				Cond: &Binary{
+					Op: "||",
+					X:  &Ident{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+					Y: &Unary{
+						Op:    "!",
+						X:     &Ident{Name: "b", Token: lex.Token{T: lex.Identifyer, Value: "b"}},
+						Token: lex.Token{T: lex.Bang, Value: "!"},
+					},
+					Token: lex.Token{T: lex.Or, Value: "||"},
+				},
+				Then: &Block{Token: lex.Token{T: lex.OpenBrace, Value: "{"}},
+				Else: &If{
+					Cond:  &Ident{Name: "b", Token: lex.Token{T: lex.Identifyer, Value: "b"}},
+					Then:  &Block{Token: lex.Token{T: lex.OpenBrace, Value: "{"}},
+					Else:  &Block{Token: lex.Token{T: lex.OpenBrace, Value: "{"}},
+					Token: lex.Token{T: lex.IfKeyword, Value: "if"},
+				},
+				Token: lex.Token{T: lex.IfKeyword, Value: "if"},
+			},
+			false,
+		},
+		{
+			"append array literal",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Append, Value: "<-"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.Number, Value: "1"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Number, Value: "2"},
+					{T: lex.SemiColon, Value: ";"},
+					{T: lex.CloseSquare, Value: "]"},
+				},
+			},
+			&Append{
+				X: &Ident{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+				Value: &ArrayLit{
+					Elems: []Node{
+						&Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}},
+						&Int{Value: "2", Token: lex.Token{T: lex.Number, Value: "2"}},
+					},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Token: lex.Token{T: lex.Append, Value: "<-"},
+			},
+			false,
+		},
+		{
+			"compound index assignment",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "j"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.Number, Value: "1"},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.PlusEqual, Value: "+="},
+					{T: lex.Number, Value: "1"},
+				},
+			},
+			&Assign{
+				Target: &Index{
+					X:     &Ident{Name: "j", Token: lex.Token{T: lex.Identifyer, Value: "j"}},
+					Index: &Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Value: &Binary{
+					Op: "+",
+					X: &Index{
+						X:     &Ident{Name: "j", Token: lex.Token{T: lex.Identifyer, Value: "j"}},
+						Index: &Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}},
+						Token: lex.Token{T: lex.OpenSquare, Value: "["},
+					},
+					Y:     &Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}},
+					Token: lex.Token{T: lex.PlusEqual, Value: "+="},
+				},
+				Token: lex.Token{T: lex.PlusEqual, Value: "+="},
+			},
+			false,
+		},
+		{
+			"pop a slice",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Number, Value: "3"},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.Pop, Value: "->"},
+				},
+			},
+			&Pop{
+				X: &Slice{
+					X:     &Ident{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+					High:  &Int{Value: "3", Token: lex.Token{T: lex.Number, Value: "3"}},
+					Token: lex.Token{T: lex.OpenSquare, Value: "["},
+				},
+				Token: lex.Token{T: lex.Pop, Value: "->"},
+			},
+			false,
+		},
+		{
+			"cmd with arguments",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "c"},
+					{T: lex.DoubleColon, Value: "::"},
+					{T: lex.Identifyer, Value: "echo"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.String, Value: "hello"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.String, Value: "world"},
+					{T: lex.CloseSquare, Value: "]"},
+				},
+			},
+			&Var{
+				Name: "c",
+				Default: &Cmd{
+					Name: &Ident{Name: "echo", Token: lex.Token{T: lex.Identifyer, Value: "echo"}},
+					Args: []Node{
+						&String{Value: "hello", Token: lex.Token{T: lex.String, Value: "hello"}},
+						&String{Value: "world", Token: lex.Token{T: lex.String, Value: "world"}},
+					},
+					Token: lex.Token{T: lex.Identifyer, Value: "echo"},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "c"},
+			},
+			false,
+		},
+		{
+			"cmd without arguments",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "c"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.CmdType, Value: "cmd"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Identifyer, Value: "ls"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+				},
+			},
+			&Var{
+				Name: "c",
+				Type: &TypeName{Name: "cmd", Token: lex.Token{T: lex.CmdType, Value: "cmd"}},
+				Default: &Cmd{
+					Name:  &Ident{Name: "ls", Token: lex.Token{T: lex.Identifyer, Value: "ls"}},
+					Token: lex.Token{T: lex.Identifyer, Value: "ls"},
+				},
+				Token: lex.Token{T: lex.Identifyer, Value: "c"},
+			},
+			false,
+		},
+		{
+			"pipeline",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Exec, Value: "$"},
+					{T: lex.Identifyer, Value: "echo"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.Pipe, Value: "|"},
+					{T: lex.Identifyer, Value: "f"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.CloseParen, Value: ")"},
+				},
+			},
+			&Pipeline{
+				Stages: []Node{
+					&Exec{
+						Expr: &Cmd{
+							Name:  &Ident{Name: "echo", Token: lex.Token{T: lex.Identifyer, Value: "echo"}},
+							Token: lex.Token{T: lex.Identifyer, Value: "echo"},
+						},
+						Token: lex.Token{T: lex.Exec, Value: "$"},
+					},
+					&Call{
+						Fn:    &Ident{Name: "f", Token: lex.Token{T: lex.Identifyer, Value: "f"}},
+						Token: lex.Token{T: lex.OpenParen, Value: "("},
+					},
+				},
+				Token: lex.Token{T: lex.Pipe, Value: "|"},
+			},
+			false,
+		},
+		{
+			"pipe into a value",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "f"},
+					{T: lex.OpenParen, Value: "("},
+					{T: lex.CloseParen, Value: ")"},
+					{T: lex.Pipe, Value: "|"},
+					{T: lex.String, Value: "a"},
+				},
+			},
+			nil,
+			true,
+		},
+		{
+			"missing index",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.OpenSquare, Value: "["},
+					{T: lex.CloseSquare, Value: "]"},
+					{T: lex.Pop, Value: "->"},
+				},
+			},
+			nil,
+			true,
+		},
+		{
+			"precedence",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Colon, Value: ":"},
+					{T: lex.Number, Value: "1"},
+					{T: lex.Plus, Value: "+"},
+					{T: lex.Number, Value: "2"},
+					{T: lex.Star, Value: "*"},
+					{T: lex.Number, Value: "3"},
+				},
+			},
+			&Assign{
+				Target: &Ident{Name: "a", Token: lex.Token{T: lex.Identifyer, Value: "a"}},
+				Value: &Binary{
+					Op: "+",
+					X:  &Int{Value: "1", Token: lex.Token{T: lex.Number, Value: "1"}},
+					Y: &Binary{
+						Op:    "*",
+						X:     &Int{Value: "2", Token: lex.Token{T: lex.Number, Value: "2"}},
+						Y:     &Int{Value: "3", Token: lex.Token{T: lex.Number, Value: "3"}},
+						Token: lex.Token{T: lex.Star, Value: "*"},
+					},
+					Token: lex.Token{T: lex.Plus, Value: "+"},
+				},
+				Token: lex.Token{T: lex.Colon, Value: ":"},
+			},
+			false,
+		},
+		{
+			"expression statement",
+			args{
+				tokens: []lex.Token{
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Plus, Value: "+"},
+					{T: lex.Identifyer, Value: "b"},
+				},
+			},
+			nil,
+			true,
+		},
+		{
+			"too many loop clauses",
+			args{
+				tokens: []lex.Token{
+					{T: lex.LoopKeyword, Value: "loop"},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.Comma, Value: ","},
+					{T: lex.Identifyer, Value: "a"},
+					{T: lex.OpenBrace, Value: "{"},
+					{T: lex.CloseBrace, Value: "}"},
+				},
+			},
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newParser(tt.args.tokens).parseStmt()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStmt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStmt() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
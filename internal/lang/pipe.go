@@ -0,0 +1,56 @@
+package lang
+
+import (
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+type Pipeline struct {
+	Stages []Node
+	Token  lex.Token
+}
+
+func (n *Pipeline) Children() []Node {
+	return n.Stages
+}
+
+func (n *Pipeline) Pos() lex.Token {
+	return n.Token
+}
+
+// parsePipeline parses an expression followed by any number of piped stages
+// every stage of a pipeline must be an exec or a function call
+//
+//	$echo["hi"] | needPipe()
+func (p *parser) parsePipeline() (Node, error) {
+	x, err := p.parseBinary(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.is(lex.Pipe) {
+		return x, nil
+	}
+
+	pipe := &Pipeline{Stages: []Node{x}, Token: p.peek(0)}
+	for {
+		if _, ok := p.accept(lex.Pipe); !ok {
+			break
+		}
+
+		stage, err := p.parseBinary(1)
+		if err != nil {
+			return nil, err
+		}
+		pipe.Stages = append(pipe.Stages, stage)
+	}
+
+	for _, stage := range pipe.Stages {
+		switch stage.(type) {
+		case *Exec, *Call:
+		default:
+			return nil, syntaxError(stage.Pos(), "only commands and function calls can be piped")
+		}
+	}
+
+	return pipe, nil
+}
@@ -0,0 +1,231 @@
+package lang
+
+import (
+	"github.com/bjatkin/blow-k/internal/lex"
+)
+
+type StructType struct {
+	Fields  []*Var
+	Methods []*Var
+	Token   lex.Token
+}
+
+func (n *StructType) Children() []Node {
+	var children []Node
+	for _, field := range n.Fields {
+		children = append(children, field)
+	}
+	for _, method := range n.Methods {
+		children = append(children, method)
+	}
+
+	return children
+}
+
+func (n *StructType) Pos() lex.Token {
+	return n.Token
+}
+
+// Field returns the field with the given name
+func (n *StructType) Field(name string) (*Var, bool) {
+	for _, field := range n.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	return nil, false
+}
+
+// Method returns the method with the given name
+func (n *StructType) Method(name string) (*Var, bool) {
+	for _, method := range n.Methods {
+		if method.Name == name {
+			return method, true
+		}
+	}
+
+	return nil, false
+}
+
+type StructLit struct {
+	Fields []*KeyValue
+	Token  lex.Token
+}
+
+func (n *StructLit) Children() []Node {
+	var children []Node
+	for _, field := range n.Fields {
+		children = append(children, field)
+	}
+
+	return children
+}
+
+func (n *StructLit) Pos() lex.Token {
+	return n.Token
+}
+
+// Field returns the value set for the given field name
+func (n *StructLit) Field(name string) (Node, bool) {
+	for _, field := range n.Fields {
+		if field.Key.Name == name {
+			return field.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+type KeyValue struct {
+	Key   *Ident
+	Value Node
+	Token lex.Token
+}
+
+func (n *KeyValue) Children() []Node {
+	return []Node{n.Key, n.Value}
+}
+
+func (n *KeyValue) Pos() lex.Token {
+	return n.Token
+}
+
+type Field struct {
+	X     Node
+	Name  *Ident
+	Token lex.Token
+}
+
+func (n *Field) Children() []Node {
+	return []Node{n.X, n.Name}
+}
+
+func (n *Field) Pos() lex.Token {
+	return n.Token
+}
+
+// parseStructType parses a struct type, fields and methods can be separated by commas or semicolons
+//
+//	< x, y:int: 5; add:(b:v2): { ... } >
+func (p *parser) parseStructType() (*StructType, error) {
+	open, err := p.expect(lex.LessThan)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &StructType{Token: open}
+	for {
+		p.skip(lex.SemiColon, lex.Comma)
+		if _, ok := p.accept(lex.GreaterThan); ok {
+			return st, nil
+		}
+
+		fields, err := p.parseFields()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range fields {
+			if _, ok := field.Default.(*Func); ok {
+				st.Methods = append(st.Methods, field)
+			} else {
+				st.Fields = append(st.Fields, field)
+			}
+		}
+
+		if !p.is(lex.SemiColon, lex.Comma, lex.GreaterThan) {
+			return nil, syntaxError(p.peek(0), "expected "+lex.GreaterThan.String())
+		}
+	}
+}
+
+// parseFields parses a list of field names that share a type and default value
+// the default ends at a > that closes the struct, comparisons with > have to be put in parens
+//
+//	x, y:int: 5
+func (p *parser) parseFields() ([]*Var, error) {
+	var names []lex.Token
+	for {
+		name, err := p.expect(lex.Identifyer)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+
+		if _, ok := p.accept(lex.Comma); !ok {
+			break
+		}
+	}
+
+	if _, err := p.expect(lex.Colon); err != nil {
+		return nil, err
+	}
+
+	typ, err := p.parseType()
+	if err != nil {
+		return nil, err
+	}
+
+	var def Node
+	if _, ok := p.accept(lex.Colon); ok {
+		saved := p.fieldDefault
+		p.fieldDefault = true
+		def, err = p.parseDefault(typ)
+		p.fieldDefault = saved
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var fields []*Var
+	for _, name := range names {
+		fields = append(fields, &Var{Name: name.Value, Type: typ, Default: def, Token: name})
+	}
+
+	return fields, nil
+}
+
+// parseStructLit parses a struct literal, fields can be separated by commas or semicolons
+//
+//	{ x: 5, y: 10 }
+func (p *parser) parseStructLit() (*StructLit, error) {
+	defer p.group()()
+
+	open, err := p.expect(lex.OpenBrace)
+	if err != nil {
+		return nil, err
+	}
+
+	lit := &StructLit{Token: open}
+	for {
+		p.skip(lex.SemiColon, lex.Comma)
+		if _, ok := p.accept(lex.CloseBrace); ok {
+			return lit, nil
+		}
+
+		key, err := p.expect(lex.Identifyer)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := p.expect(lex.Colon); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+
+		lit.Fields = append(lit.Fields, &KeyValue{
+			Key:   &Ident{Name: key.Value, Token: key},
+			Value: value,
+			Token: key,
+		})
+
+		if !p.is(lex.SemiColon, lex.Comma, lex.CloseBrace) {
+			return nil, syntaxError(p.peek(0), "expected "+lex.CloseBrace.String())
+		}
+	}
+}
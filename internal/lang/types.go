@@ -0,0 +1,193 @@
+package lang
+
+// SameType returns true if both types describe the same values
+func SameType(a, b Node) bool {
+	switch x := a.(type) {
+	case *TypeName:
+		y, ok := b.(*TypeName)
+		return ok && x.Name == y.Name
+	case *ArrayType:
+		y, ok := b.(*ArrayType)
+		return ok && SameType(x.Elem, y.Elem)
+	case *FuncType:
+		y, ok := b.(*FuncType)
+		if !ok || len(x.Params) != len(y.Params) || len(x.Inputs) != len(y.Inputs) || len(x.Returns) != len(y.Returns) {
+			return false
+		}
+		for i := range x.Params {
+			if !SameType(x.Params[i].Type, y.Params[i].Type) {
+				return false
+			}
+		}
+		for i := range x.Returns {
+			if !SameType(x.Returns[i], y.Returns[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+// IsArray returns true if the type is an array
+func IsArray(typ Node) bool {
+	_, ok := typ.(*ArrayType)
+	return ok
+}
+
+// IsFn returns true if the type is a function type
+func IsFn(typ Node) bool {
+	_, ok := typ.(*FuncType)
+	return ok
+}
+
+// IsCmd returns true if the type is a cmd
+func IsCmd(typ Node) bool {
+	name, ok := typ.(*TypeName)
+	return ok && name.Name == "cmd"
+}
+
+// IsInt returns true if the type is an int
+func IsInt(typ Node) bool {
+	name, ok := typ.(*TypeName)
+	return ok && name.Name == "int"
+}
+
+// IsBool returns true if the type is a bool
+func IsBool(typ Node) bool {
+	name, ok := typ.(*TypeName)
+	return ok && name.Name == "bool"
+}
+
+// IsString returns true if the type is a string
+func IsString(typ Node) bool {
+	name, ok := typ.(*TypeName)
+	return ok && name.Name == "string"
+}
+
+// IsLogical returns true if the operator joins two conditions
+func IsLogical(op string) bool {
+	return op == "&&" || op == "||"
+}
+
+// IsComparison returns true if the operator compares two values
+func IsComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		return true
+	default:
+		return false
+	}
+}
+
+// IsFuncDecl returns true if the variable declares a function
+func (n *Var) IsFuncDecl() bool {
+	_, ok := n.Default.(*Func)
+	return ok
+}
+
+// IsTypeDecl returns true if the variable declares a struct type
+func (n *Var) IsTypeDecl() bool {
+	_, ok := n.Default.(*StructType)
+	return ok
+}
+
+// LocalName returns the name an import is referenced by
+func (n *Import) LocalName() string {
+	if n.As != "" {
+		return n.As
+	}
+
+	return n.Name
+}
+
+// TypeScope maps variable names to their types and type names to struct types
+type TypeScope struct {
+	Parent *TypeScope
+	vars   map[string]Node
+	types  map[string]*StructType
+}
+
+// NewTypeScope creates a new scope inside of the parent scope
+func NewTypeScope(parent *TypeScope) *TypeScope {
+	return &TypeScope{
+		Parent: parent,
+		vars:   make(map[string]Node),
+		types:  make(map[string]*StructType),
+	}
+}
+
+// Declare adds a variable to the scope, variables that failed to check are declared with a nil type
+func (s *TypeScope) Declare(name string, typ Node) {
+	s.vars[name] = typ
+}
+
+// Declared returns the type of a variable if it is declared in this scope, parent scopes are not searched
+func (s *TypeScope) Declared(name string) (Node, bool) {
+	typ, ok := s.vars[name]
+	return typ, ok
+}
+
+// Lookup finds the type of a variable in the scope or any of its parents
+func (s *TypeScope) Lookup(name string) (Node, bool) {
+	for ; s != nil; s = s.Parent {
+		if typ, ok := s.vars[name]; ok {
+			return typ, true
+		}
+	}
+
+	return nil, false
+}
+
+// DeclareType adds a named struct type to the scope
+func (s *TypeScope) DeclareType(name string, typ *StructType) {
+	s.types[name] = typ
+}
+
+// LookupType finds a named struct type in the scope or any of its parents
+func (s *TypeScope) LookupType(name string) (*StructType, bool) {
+	for ; s != nil; s = s.Parent {
+		if typ, ok := s.types[name]; ok {
+			return typ, true
+		}
+	}
+
+	return nil, false
+}
+
+// StructType resolves the struct type of a variable type
+func (s *TypeScope) StructType(typ Node) (*StructType, bool) {
+	switch v := typ.(type) {
+	case *StructType:
+		return v, true
+	case *TypeName:
+		return s.LookupType(v.Name)
+	default:
+		return nil, false
+	}
+}
+
+// CmdLit returns the cmd literal for a node
+// a command with a single argument is parsed as an index so an index of an imported command
+// that is not shadowed by a variable is also a cmd literal
+func (s *TypeScope) CmdLit(node Node, imported func(name string) bool) (*Cmd, bool) {
+	switch v := node.(type) {
+	case *Cmd:
+		return v, true
+	case *Index:
+		name, ok := v.X.(*Ident)
+		if !ok {
+			return nil, false
+		}
+		if _, ok := s.Lookup(name.Name); ok {
+			return nil, false
+		}
+		if !imported(name.Name) {
+			return nil, false
+		}
+		return &Cmd{Name: name, Args: []Node{v.Index}, Token: name.Token}, true
+	default:
+		return nil, false
+	}
+}
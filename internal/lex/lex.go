@@ -30,27 +30,69 @@ func NewClient() *Client {
 		matchers: []matcher{
 			newSMatcher("import", ImportKeyword),
 			newSMatcher("as", AsKeyword),
+			newSMatcher("from", FromKeyword),
+			newSMatcher("return", ReturnKeyword),
+			newSMatcher("loop", LoopKeyword),
+			newSMatcher("if", IfKeyword),
+			newSMatcher("else", ElseKeyword),
 			newSMatcher("#", StartComment),
 			newSMatcher(":", Colon),
+			newSMatcher("::", DoubleColon),
 			newSMatcher(",", Comma),
+			newSMatcher(".", Dot),
 			newSMatcher("$", Exec),
 			newSMatcher(";", SemiColon),
 			newSMatcher("\n", NewLine),
 			newSMatcher(" ", WhiteSpace),
+			newSMatcher("\t", WhiteSpace),
 			newSMatcher("(", OpenParen),
 			newSMatcher(")", CloseParen),
 			newSMatcher("[", OpenSquare),
 			newSMatcher("]", CloseSquare),
 			newSMatcher("{", OpenBrace),
 			newSMatcher("}", CloseBrace),
+			newSMatcher("|", Pipe),
+			newSMatcher("<", LessThan),
+			newSMatcher(">", GreaterThan),
+			newSMatcher("=", Equal),
+			newSMatcher("!", Bang),
+			newSMatcher("&", Amp),
+			newSMatcher("+", Plus),
+			newSMatcher("-", Minus),
+			newSMatcher("*", Star),
+			newSMatcher("/", Slash),
+			newSMatcher("%", Percent),
+			newSMatcher("^", Caret),
+			newSMatcher("<-", Append),
+			newSMatcher("->", Pop),
+			newSMatcher("++", Increment),
+			newSMatcher("--", Decrement),
+			newSMatcher("**", Power),
+			newSMatcher("&&", And),
+			newSMatcher("||", Or),
+			newSMatcher("<=", LessEqual),
+			newSMatcher(">=", GreaterEqual),
+			newSMatcher("==", DoubleEqual),
+			newSMatcher("!=", NotEqual),
+			newSMatcher("+=", PlusEqual),
+			newSMatcher("-=", MinusEqual),
+			newSMatcher("*=", StarEqual),
+			newSMatcher("/=", SlashEqual),
+			newSMatcher("%=", PercentEqual),
 			newSMatcher("\"", StartEndString),
 			newSMatcher("string", StringType),
-			newRMatcher(`[a-zA-Z][a-zA-Z0-9_]+`, Identifyer),
+			newSMatcher("int", IntType),
+			newSMatcher("bool", BoolType),
+			newSMatcher("cmd", CmdType),
+			newSMatcher("true", Bool),
+			newSMatcher("false", Bool),
+			newRMatcher(`[0-9]+`, Number),
+			newRMatcher(`[a-zA-Z][a-zA-Z0-9_]*`, Identifyer),
 		},
 		transformers: []transformer{
-			coalesceStrings,
-			coalesceArrayTypes,
 			coalesceComments,
+			coalesceStrings,
+			coalesceNegativeNumbers,
 			insertSemicolons,
 			filter,
 		},
@@ -58,7 +100,8 @@ func NewClient() *Client {
 }
 
 // Lex converts a slice of tok.Token into a slice of lex.Tokens
-func (c *Client) Lex(tokens []tok.Token) []Token {
+// every token that is not valid blowK is reported, the tokens are returned even if there are errors
+func (c *Client) Lex(tokens []tok.Token) ([]Token, error) {
 	var ret []Token
 
 	for _, tok := range tokens {
@@ -82,7 +125,29 @@ func (c *Client) Lex(tokens []tok.Token) []Token {
 		ret = t(ret)
 	}
 
-	return ret
+	diags := errors.NewCollector(0)
+	for _, t := range ret {
+		switch t.T {
+		case Unknown:
+			diags.Add(lexError(t, errors.InvalidTokenCode, "invalid token "+t.Value))
+		case StartEndString:
+			// quotes are only left over if a string is never closed
+			diags.Add(lexError(t, errors.UnclosedStringCode, "string literal not terminated"))
+		}
+	}
+
+	return ret, diags.Err()
+}
+
+// lexError returns an error positioned at the token
+func lexError(t Token, code, msg string) error {
+	return errors.New(
+		bear.WithErrType(errors.SyntaxError),
+		bear.WithExitCode(errors.LexerFailed),
+		bear.WithLabels(msg),
+		errors.WithCode(code),
+		errors.At(t.FileName, t.LineNumber, t.ColNumber, t.Value),
+	)
 }
 
 // matcher matches a token with a token type
@@ -111,6 +176,7 @@ func newRMatcher(reg string, t TokType) matcher {
 }
 
 // match returns true if the matcher matches the token
+// a matcher without a string or regex never matches
 func (m matcher) match(token tok.Token) bool {
 	sToken := token.Value
 	if m.str != "" {
@@ -121,11 +187,5 @@ func (m matcher) match(token tok.Token) bool {
 		return m.reg.MatchString(sToken)
 	}
 
-	// panic if str and reg are both unset
-	errors.New(
-		bear.WithExitCode(errors.LexerFailed),
-		bear.WithErrType(errors.InvalidLexMatcher),
-	).Panic(true)
-
 	return false
 }
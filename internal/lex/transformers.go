@@ -1,10 +1,5 @@
 package lex
 
-import (
-	"github.com/bjatkin/bear"
-	"github.com/bjatkin/blow-k/internal/errors"
-)
-
 // transformer is a function that transforms the lex.Token slice into a different slice
 type transformer func([]Token) []Token
 
@@ -12,17 +7,19 @@ type transformer func([]Token) []Token
 func coalesceStrings(tokens []Token) []Token {
 	var ret []Token
 	var collect []Token
+	var start Token
 	var open bool
 
 	for _, tok := range tokens {
 		if tok.T == StartEndString && open {
-			ret = append(ret, combineTokens(String, collect))
+			ret = append(ret, stringToken(start, collect))
 			open = false
 			collect = []Token{}
 			continue
 		}
 
 		if tok.T == StartEndString && !open {
+			start = tok
 			open = true
 			continue
 		}
@@ -35,23 +32,41 @@ func coalesceStrings(tokens []Token) []Token {
 		ret = append(ret, tok)
 	}
 
+	// the opening quote is kept if the string is never closed so it can be reported
+	if open {
+		ret = append(ret, start)
+		ret = append(ret, collect...)
+	}
+
 	return ret
 }
 
+// stringToken combines the tokens following the start quote into a single string literal
+func stringToken(start Token, collect []Token) Token {
+	if len(collect) == 0 {
+		return Token{T: String, FileName: start.FileName, LineNumber: start.LineNumber, ColNumber: start.ColNumber + 1}
+	}
+
+	return combineTokens(String, collect)
+}
+
 // coalesceComments combines tokens in to comments
+// comment markers inside of string literals are ignored
 func coalesceComments(tokens []Token) []Token {
 	var ret []Token
 	var collect []Token
-	var open bool
+	var start Token
+	var open, inString bool
 
 	for _, tok := range tokens {
 		if tok.T == NewLine && open {
-			ret = append(ret, combineTokens(Comment, collect))
+			ret = append(ret, commentToken(start, collect))
 			collect = []Token{}
 			open = false
 		}
 
-		if tok.T == StartComment {
+		if tok.T == StartComment && !open && !inString {
+			start = tok
 			open = true
 			continue
 		}
@@ -61,49 +76,82 @@ func coalesceComments(tokens []Token) []Token {
 			continue
 		}
 
+		if tok.T == StartEndString {
+			inString = !inString
+		}
+
 		ret = append(ret, tok)
 	}
 
+	// make sure to get a comment at the end of the file
+	if open {
+		ret = append(ret, commentToken(start, collect))
+	}
+
 	return ret
 }
 
-// coalesceArrayTypes combines tokens into a single array type
-func coalesceArrayTypes(tokens []Token) []Token {
+// commentToken combines the tokens following the start token into a single comment
+func commentToken(start Token, collect []Token) Token {
+	if len(collect) == 0 {
+		return Token{T: Comment, FileName: start.FileName, LineNumber: start.LineNumber, ColNumber: start.ColNumber + 1}
+	}
+
+	return combineTokens(Comment, collect)
+}
+
+// coalesceNegativeNumbers combines a minus sign with the number directly after it
+// the minus is only part of the number when it can not be a binary operator
+//
+//	[10, -4]  ->  10 , -4
+//	a -4      ->  a - 4
+func coalesceNegativeNumbers(tokens []Token) []Token {
 	var ret []Token
 	for i := 0; i < len(tokens); i++ {
 		tok := tokens[i]
-		if i+2 > len(tokens) {
+		if tok.T != Minus ||
+			i+1 >= len(tokens) ||
+			tokens[i+1].T != Number ||
+			tokens[i+1].LineNumber != tok.LineNumber ||
+			tokens[i+1].ColNumber != tok.ColNumber+1 ||
+			IsOperand(lastToken(ret)) {
 			ret = append(ret, tok)
 			continue
 		}
 
-		// check for leading []
-		if tokens[i].T != OpenSquare ||
-			tokens[i+1].T != CloseSquare {
-			ret = append(ret, tok)
-			continue
-		}
+		ret = append(ret, combineTokens(Number, tokens[i:i+2]))
+		i++
+	}
 
-		// check for type
-		if tokens[i+2].T == StringType {
-			ret = append(ret, combineTokens(StringArrayType, tokens[i:i+3]))
-			i += 2
-			continue
-		}
+	return ret
+}
 
-		ret = append(ret, tok)
+// lastToken returns the last token that is not white space
+func lastToken(tokens []Token) Token {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if tokens[i].T != WhiteSpace {
+			return tokens[i]
+		}
 	}
 
-	return ret
+	return Token{}
+}
+
+// IsOperand returns true if a minus after the token would subtract from it
+func IsOperand(tok Token) bool {
+	switch tok.T {
+	case Identifyer, Number, Bool, String, CloseParen, CloseSquare, CloseBrace:
+		return true
+	default:
+		return false
+	}
 }
 
 // combineTokens combines tok.Tokens into a single Token
+// combining no tokens returns an empty token of the given type
 func combineTokens(t TokType, tokens []Token) Token {
 	if len(tokens) == 0 {
-		errors.New(
-			bear.WithExitCode(errors.LexerFailed),
-			bear.WithTag("tokens", 0),
-		).Panic(true)
+		return Token{T: t}
 	}
 
 	token := Token{
@@ -133,6 +181,7 @@ func insertSemicolons(tokens []Token) []Token {
 			prev == OpenParen ||
 			prev == OpenSquare ||
 			prev == Comma ||
+			prev == Pipe ||
 			prev == SemiColon {
 			continue
 		}
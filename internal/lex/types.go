@@ -43,13 +43,19 @@ const (
 	ImportKeyword
 	AsKeyword
 	FromKeyword
+	ReturnKeyword
+	LoopKeyword
+	IfKeyword
+	ElseKeyword
 
 	StartComment
 	Comment
 	String
 
 	Colon
+	DoubleColon
 	Comma
+	Dot
 	Exec
 	SemiColon
 	NewLine
@@ -62,11 +68,44 @@ const (
 	OpenBrace
 	CloseBrace
 
+	Pipe
+	LessThan
+	GreaterThan
+	Equal
+	Bang
+	Amp
+	Plus
+	Minus
+	Star
+	Slash
+	Percent
+	Caret
+	Append
+	Pop
+	Increment
+	Decrement
+	Power
+	And
+	Or
+	LessEqual
+	GreaterEqual
+	DoubleEqual
+	NotEqual
+	PlusEqual
+	MinusEqual
+	StarEqual
+	SlashEqual
+	PercentEqual
+
 	StartEndString
 
 	StringType
-	StringArrayType
+	IntType
+	BoolType
+	CmdType
 
+	Number
+	Bool
 	Identifyer
 )
 
@@ -76,11 +115,17 @@ var tokenStrings = [...]string{
 	"ImportKeyword",
 	"AsKeyword",
 	"FromKeyword",
+	"ReturnKeyword",
+	"LoopKeyword",
+	"IfKeyword",
+	"ElseKeyword",
 	"StartComment",
 	"Comment",
 	"String",
 	"Colon",
+	"DoubleColon",
 	"Comma",
+	"Dot",
 	"Exec",
 	"SemiColon",
 	"NewLine",
@@ -91,8 +136,40 @@ var tokenStrings = [...]string{
 	"CloseSquare",
 	"OpenBrace",
 	"CloseBrace",
+	"Pipe",
+	"LessThan",
+	"GreaterThan",
+	"Equal",
+	"Bang",
+	"Amp",
+	"Plus",
+	"Minus",
+	"Star",
+	"Slash",
+	"Percent",
+	"Caret",
+	"Append",
+	"Pop",
+	"Increment",
+	"Decrement",
+	"Power",
+	"And",
+	"Or",
+	"LessEqual",
+	"GreaterEqual",
+	"DoubleEqual",
+	"NotEqual",
+	"PlusEqual",
+	"MinusEqual",
+	"StarEqual",
+	"SlashEqual",
+	"PercentEqual",
 	"StartEndString",
 	"StringType",
-	"StringArrayType",
+	"IntType",
+	"BoolType",
+	"CmdType",
+	"Number",
+	"Bool",
 	"Identifyer",
 }
@@ -0,0 +1,378 @@
+package load
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/blow-k/internal/check"
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lang"
+	"github.com/bjatkin/blow-k/internal/lex"
+	"github.com/bjatkin/blow-k/internal/tok"
+)
+
+// Client is a loading client that reads a blowK source file along with every file it imports declarations from
+type Client struct{}
+
+// NewClient creates a new default load.Client
+func NewClient() *Client {
+	return &Client{}
+}
+
+// Load parses the source file and bundles it with every file it imports from into a single lang.Root
+// the top level names of imported files are prefixed with the name of their file so they can not clash,
+// every reference to an imported name is renamed to match, declarations that shadow another name
+// or clash with a bash variable are given a unique name the same way
+// every file is loaded even if another file has errors so all the errors are reported at once
+// the warnings of every file are returned along with the bundle, they are found before any names are prefixed
+func (c *Client) Load(srcFile string) (lang.Node, []check.Warning, error) {
+	l := &loader{
+		dir:      filepath.Dir(srcFile),
+		modules:  make(map[string]*module),
+		prefixes: make(map[string]bool),
+		bundle:   &lang.Root{},
+		diags:    errors.NewCollector(0),
+	}
+
+	l.load(srcFile, nil)
+	if err := l.diags.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return l.bundle, l.warnings, nil
+}
+
+// loader holds the state for a single call to Load
+type loader struct {
+	// dir is the directory of the source file, paths in errors are relative to it
+	dir string
+
+	// modules are the loaded files by their path
+	modules map[string]*module
+
+	// stack is the path of every file that is being loaded, in the order they were imported
+	stack []string
+
+	// prefixes are the name prefixes that are already used by a loaded file
+	prefixes map[string]bool
+
+	// bundle is the root that every loaded file is added to
+	bundle *lang.Root
+
+	// diags collects the errors from every file
+	diags *errors.Collector
+
+	// warnings are the warnings from every file, in the order the files were parsed
+	warnings []check.Warning
+
+	// renamed counts the declarations given a unique name so every new name is different
+	renamed int
+}
+
+// module is a single loaded file
+type module struct {
+	// names maps the top level names of the file to their names in the bundle
+	names map[string]string
+
+	// loaded is false if the file had errors and was not added to the bundle
+	loaded bool
+}
+
+// load parses a file and adds it to the bundle after every file it imports from
+// imp is the import that loads the file, it is nil for the source file
+// errors are added to the diagnostics and nil is returned if the file could not be loaded
+func (l *loader) load(path string, imp *lang.Import) *module {
+	path = filepath.Clean(path)
+	for i, prev := range l.stack {
+		if prev == path {
+			l.diags.Add(loadError(imp, errors.ImportCycle, errors.ImportCycleCode,
+				"import cycle: "+l.cycle(l.stack[i:]),
+				errors.WithHint("move the shared declarations into a file that does not import any of these files"),
+			))
+			return nil
+		}
+	}
+	if m, ok := l.modules[path]; ok {
+		return m
+	}
+
+	if _, err := os.Stat(path); err != nil && imp != nil {
+		l.diags.Add(loadError(imp, errors.FileNotFound, errors.ImportNotFoundCode,
+			"could not find file "+imp.From,
+			errors.WithHint("relative paths start from the file that imports them"),
+		))
+		return nil
+	}
+
+	l.stack = append(l.stack, path)
+	defer func() {
+		l.stack = l.stack[:len(l.stack)-1]
+	}()
+
+	// files that fail to load are still added to the modules so their errors are only reported once
+	m := &module{names: make(map[string]string)}
+	l.modules[path] = m
+
+	root, res, ok := l.parse(path)
+	if !ok {
+		// the imported files are still loaded so their errors are reported too
+		if root != nil {
+			for _, node := range root.Imports {
+				if v := node.(*lang.Import); v.From != "" {
+					l.load(importPath(path, v.From), v)
+				}
+			}
+		}
+		return nil
+	}
+
+	// the source file keeps its names, every imported file gets a prefix
+	prefix := ""
+	if imp != nil {
+		prefix = l.prefix(path)
+		if root.Main != nil {
+			l.diags.Add(loadError(root.Main, errors.SyntaxError, errors.SyntaxCode, "main can only be declared in the file being built"))
+		}
+	}
+
+	r := newRenamer(res)
+
+	for _, node := range root.Imports {
+		v := node.(*lang.Import)
+		if v.From == "" {
+			// command imports are renamed with an alias so each file keeps its own names for its commands
+			if prefix != "" {
+				v.As = r.rename(v, prefix+v.LocalName())
+			}
+			l.bundle.Imports = append(l.bundle.Imports, v)
+			continue
+		}
+
+		dep := l.load(importPath(path, v.From), v)
+		if dep == nil || !dep.loaded {
+			continue
+		}
+
+		name, ok := dep.names[v.Name]
+		if !ok {
+			l.diags.Add(loadError(v, errors.UndefinedName, errors.UndefinedCode, v.Name+" is not declared in "+v.From))
+			continue
+		}
+		r.rename(v, name)
+	}
+
+	for _, node := range root.Expressions {
+		v := node.(*lang.Var)
+		m.names[v.Name] = prefix + v.Name
+		v.Name = r.rename(v, prefix+v.Name)
+	}
+	l.unique(r, prefix != "")
+
+	l.bundle.Expressions = append(l.bundle.Expressions, root.Expressions...)
+	if imp == nil {
+		l.bundle.Main = root.Main
+	}
+
+	m.loaded = true
+	return m
+}
+
+// parse tokenizes, lexes, parses and resolves a single file
+// false is returned if there were any errors, the errors are added to the diagnostics
+// the root is still returned if only the names could not be resolved
+func (l *loader) parse(path string) (*lang.Root, *check.Resolution, bool) {
+	tokens, err := tok.NewClient().Tokenize(path)
+	if err != nil {
+		l.diags.Add(err)
+		return nil, nil, false
+	}
+
+	lexTokens, err := lex.NewClient().Lex(tokens)
+	if err != nil {
+		l.diags.Add(err)
+		return nil, nil, false
+	}
+
+	node, err := lang.NewClient().Build(lexTokens)
+	if err != nil {
+		l.diags.Add(err)
+		return nil, nil, false
+	}
+	root := node.(*lang.Root)
+
+	res, err := check.NewClient().Resolve(root)
+	if err != nil {
+		l.diags.Add(err)
+		return root, nil, false
+	}
+	l.warnings = append(l.warnings, res.Warnings...)
+
+	return root, res, true
+}
+
+// importPath returns the path of a file imported by the file at path,
+// relative paths start from the directory of the importing file and absolute paths are used as is
+func importPath(path, from string) string {
+	if filepath.IsAbs(from) {
+		return from
+	}
+
+	return filepath.Join(filepath.Dir(path), from)
+}
+
+// invalidPrefix matches every character that can not be used in a name
+var invalidPrefix = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// prefix returns a new unique name prefix for the file based on its file name
+func (l *loader) prefix(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	base = invalidPrefix.ReplaceAllString(base, "_")
+	if base == "" || (base[0] >= '0' && base[0] <= '9') {
+		base = "_" + base
+	}
+
+	prefix := base
+	for i := 2; l.prefixes[prefix]; i++ {
+		prefix = base + strconv.Itoa(i)
+	}
+	l.prefixes[prefix] = true
+
+	return prefix + "_"
+}
+
+// cycle returns the files in an import cycle, the first file is repeated at the end to close the cycle
+func (l *loader) cycle(paths []string) string {
+	var names []string
+	for _, path := range append(paths, paths[0]) {
+		if rel, err := filepath.Rel(l.dir, path); err == nil {
+			path = rel
+		}
+		names = append(names, path)
+	}
+
+	return strings.Join(names, " -> ")
+}
+
+// bashVars are the variables that change how bash behaves or that bash sets itself
+var bashVars = map[string]bool{
+	"BASH": true, "BASHOPTS": true, "BASHPID": true, "CDPATH": true, "COLUMNS": true, "DIRSTACK": true,
+	"ENV": true, "EPOCHREALTIME": true, "EPOCHSECONDS": true, "EUID": true, "FUNCNAME": true, "GLOBIGNORE": true,
+	"GROUPS": true, "HISTCMD": true, "HISTFILE": true, "HOME": true, "HOSTNAME": true, "HOSTTYPE": true,
+	"IFS": true, "LANG": true, "LINENO": true, "LINES": true, "MACHTYPE": true, "MAIL": true, "OLDPWD": true,
+	"OPTARG": true, "OPTERR": true, "OPTIND": true, "OSTYPE": true, "PATH": true, "PIPESTATUS": true,
+	"POSIXLY_CORRECT": true, "PPID": true, "PS1": true, "PS2": true, "PS3": true, "PS4": true, "PWD": true,
+	"RANDOM": true, "REPLY": true, "SECONDS": true, "SHELL": true, "SHELLOPTS": true, "SHLVL": true,
+	"SRANDOM": true, "TERM": true, "TMOUT": true, "TMPDIR": true, "UID": true, "USER": true,
+}
+
+// clashes returns true if the declared name can not be used as a bash variable as is
+// bash locals are dynamically scoped, so a local that shadows another name would be seen
+// by every function it calls, and the _bk_ prefix is kept for the variables of generated code
+// captured variables are read by name from the function literal while their function is running
+// so they need a name no other function declares
+func clashes(sym *check.Symbol) bool {
+	switch sym.Kind {
+	case check.ImportSymbol, check.ModuleSymbol, check.TypeSymbol, check.RecvSymbol:
+		return false
+	}
+
+	// me is kept so the checker can report that it is reserved for the receiver
+	if sym.Name == "me" {
+		return false
+	}
+
+	return sym.Shadows != nil ||
+		sym.Captured ||
+		bashVars[sym.Name] ||
+		strings.HasPrefix(sym.Name, "BASH_") ||
+		strings.HasPrefix(sym.Name, "LC_") ||
+		strings.HasPrefix(sym.Name, "_bk_")
+}
+
+// unique gives every declaration of the file that clashes with another bash variable a unique name
+// the top level names of imported files are already prefixed so they are skipped when prefixed is true
+func (l *loader) unique(r *renamer, prefixed bool) {
+	var decls []lang.Node
+	for decl, sym := range r.res.Decls {
+		if prefixed && sym.Scope.Kind == check.FileScope {
+			continue
+		}
+		if clashes(sym) {
+			decls = append(decls, decl)
+		}
+	}
+
+	// names are numbered in the order they are declared so the script is the same every time it is built
+	sort.Slice(decls, func(i, j int) bool {
+		a, b := decls[i].Pos(), decls[j].Pos()
+		if a.LineNumber != b.LineNumber {
+			return a.LineNumber < b.LineNumber
+		}
+		return a.ColNumber < b.ColNumber
+	})
+
+	for _, decl := range decls {
+		l.renamed++
+		name := r.rename(decl, fmt.Sprintf("_bk_%d_%s", l.renamed, r.res.Decls[decl].Name))
+		switch v := decl.(type) {
+		case *lang.Var:
+			v.Name = name
+		case *lang.Ident:
+			v.Source = v.SourceName()
+			v.Name = name
+		}
+	}
+}
+
+// renamer renames declarations along with every identifier and type name that references them
+type renamer struct {
+	res   *check.Resolution
+	types map[*check.Symbol][]*lang.TypeName
+}
+
+// newRenamer creates a renamer for the resolved names of a single file
+func newRenamer(res *check.Resolution) *renamer {
+	r := &renamer{
+		res:   res,
+		types: make(map[*check.Symbol][]*lang.TypeName),
+	}
+	for typ, sym := range res.Types {
+		r.types[sym] = append(r.types[sym], typ)
+	}
+
+	return r
+}
+
+// rename renames every reference to the name declared by the node and returns the new name
+// references keep the name they were written with as their source name so messages match the source file
+func (r *renamer) rename(decl lang.Node, name string) string {
+	sym := r.res.Decls[decl]
+	for _, ident := range sym.Uses {
+		ident.Source = ident.SourceName()
+		ident.Name = name
+	}
+	for _, typ := range r.types[sym] {
+		typ.Source = typ.SourceName()
+		typ.Name = name
+	}
+
+	return name
+}
+
+// loadError returns an error positioned at the node
+func loadError(node lang.Node, errType bear.ErrType, code, msg string, opts ...bear.ErrOption) error {
+	tok := node.Pos()
+	return errors.New(append([]bear.ErrOption{
+		bear.WithErrType(errType),
+		bear.WithExitCode(errors.LoadFailed),
+		bear.WithLabels(msg),
+		errors.WithCode(code),
+		errors.At(tok.FileName, tok.LineNumber, tok.ColNumber, tok.Value),
+	}, opts...)...)
+}
@@ -0,0 +1,284 @@
+package load
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bjatkin/bear"
+	"github.com/bjatkin/blow-k/internal/errors"
+	"github.com/bjatkin/blow-k/internal/lang"
+)
+
+// writeFiles writes every source file into a new temp directory and returns the directory
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("writeFiles() failed to create dir %v", err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatalf("writeFiles() failed to write src file %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestClient_Load(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantImports []string
+		wantDecls   []string
+		wantUses    []string
+		wantErr     string
+	}{
+		{
+			"no imported files",
+			map[string]string{
+				"main.bk": "import echo\nmain:(): {\n    $echo[\"hi\"]\n}\n",
+			},
+			[]string{"echo"},
+			nil,
+			[]string{"echo"},
+			"",
+		},
+		{
+			"imported declarations are prefixed",
+			map[string]string{
+				"main.bk":     "import add as plus from \"lib/math.bk\"\nimport v2 from \"lib/vec.bk\"\nadd :: 1\nmain:(): {\n    a:v2\n    b :: plus(add, a.x)\n}\n",
+				"lib/math.bk": "import echo as say\nadd:(a, b:int)<int>: {\n    $say[\"adding\"]\n    return a + b\n}\n",
+				"lib/vec.bk":  "v2 :: < x, y:int >\n",
+			},
+			[]string{"math_say"},
+			[]string{"math_add", "vec_v2", "add"},
+			[]string{"a", "add", "b", "math_add", "math_say"},
+			"",
+		},
+		{
+			"files imported twice are bundled once",
+			map[string]string{
+				"main.bk": "import a from \"a.bk\"\nimport b from \"b.bk\"\nmain:(): {\n    c :: a + b\n}\n",
+				"a.bk":    "import c from \"c.bk\"\na :: c\n",
+				"b.bk":    "import c from \"c.bk\"\nb :: c\n",
+				"c.bk":    "c :: 1\n",
+			},
+			nil,
+			[]string{"c_c", "a_a", "b_b"},
+			[]string{"a_a", "b_b", "c_c"},
+			"",
+		},
+		{
+			"shadowed and bash names are renamed",
+			map[string]string{
+				"main.bk": "import a from \"a.bk\"\nx :: 1\nPATH :: 2\nmain:(): {\n    x :: a + PATH\n    y :: x\n}\n",
+				"a.bk":    "a :: 1\nf:(a:int)<int>: {\n    return a\n}\n",
+			},
+			nil,
+			[]string{"a_a", "a_f", "x", "_bk_2_PATH"},
+			[]string{"_bk_1_a", "_bk_2_PATH", "_bk_3_x", "a_a"},
+			"",
+		},
+		{
+			"import cycles",
+			map[string]string{
+				"main.bk": "import a from \"a.bk\"\n",
+				"a.bk":    "import b from \"b.bk\"\na :: 1\n",
+				"b.bk":    "import a from \"a.bk\"\nb :: 1\n",
+			},
+			nil,
+			nil,
+			nil,
+			"import cycle: a.bk -> b.bk -> a.bk",
+		},
+		{
+			"undeclared names",
+			map[string]string{
+				"main.bk": "import b from \"a.bk\"\n",
+				"a.bk":    "a :: 1\n",
+			},
+			nil,
+			nil,
+			nil,
+			"b is not declared in a.bk",
+		},
+		{
+			"missing files",
+			map[string]string{
+				"main.bk": "import a from \"a.bk\"\n",
+			},
+			nil,
+			nil,
+			nil,
+			"could not find file a.bk",
+		},
+		{
+			"main in an imported file",
+			map[string]string{
+				"main.bk": "import a from \"a.bk\"\n",
+				"a.bk":    "a :: 1\nmain:(): {\n}\n",
+			},
+			nil,
+			nil,
+			nil,
+			"main can only be declared in the file being built",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+
+			node, _, err := NewClient().Load(filepath.Join(dir, "main.bk"))
+			if (err != nil) != (tt.wantErr != "") {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if berr, ok := err.(*bear.Error); !ok || !berr.HasLabel(tt.wantErr) {
+					t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			root := node.(*lang.Root)
+			var imports, decls []string
+			for _, node := range root.Imports {
+				imports = append(imports, node.(*lang.Import).LocalName())
+			}
+			for _, node := range root.Expressions {
+				decls = append(decls, node.(*lang.Var).Name)
+			}
+			if !reflect.DeepEqual(imports, tt.wantImports) {
+				t.Errorf("Load() imports = %v, want %v", imports, tt.wantImports)
+			}
+			if !reflect.DeepEqual(decls, tt.wantDecls) {
+				t.Errorf("Load() decls = %v, want %v", decls, tt.wantDecls)
+			}
+			if uses := identNames(root); !reflect.DeepEqual(uses, tt.wantUses) {
+				t.Errorf("Load() uses = %v, want %v", uses, tt.wantUses)
+			}
+		})
+	}
+}
+
+func TestClient_Load_absolute(t *testing.T) {
+	lib := writeFiles(t, map[string]string{"math.bk": "one :: 1\n"})
+	src := "import one from \"" + filepath.Join(lib, "math.bk") + "\"\nmain:(): {\n    x :: one\n}\n"
+	dir := writeFiles(t, map[string]string{"main.bk": src})
+
+	node, _, err := NewClient().Load(filepath.Join(dir, "main.bk"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	root := node.(*lang.Root)
+	if got := root.Expressions[0].(*lang.Var).Name; got != "math_one" {
+		t.Errorf("Load() decl = %v, want math_one", got)
+	}
+}
+
+// identNames returns the sorted names of every identifier in the tree, each name is only returned once
+func identNames(node lang.Node) []string {
+	seen := make(map[string]bool)
+	var walk func(node lang.Node)
+	walk = func(node lang.Node) {
+		switch v := node.(type) {
+		case *lang.Ident:
+			seen[v.Name] = true
+		case *lang.Field:
+			// field names belong to the struct type so they are never renamed
+			walk(v.X)
+		default:
+			if node == nil || reflect.ValueOf(node).IsNil() {
+				return
+			}
+			for _, child := range node.Children() {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+
+	var names []string
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func TestClient_Load_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			"syntax errors recover at the next statement",
+			map[string]string{
+				"main.bk": "main:(): {\n    a :: 1 +\n    b :: 2\n    c :: * 3\n}\n",
+			},
+			[]string{"expected an expression", "expected an expression"},
+		},
+		{
+			"lexer errors",
+			map[string]string{
+				"main.bk": "main:(): {\n    a :: @\n    b :: \"hi\n}\n",
+			},
+			[]string{"invalid token @", "string literal not terminated"},
+		},
+		{
+			"errors in every file",
+			map[string]string{
+				"main.bk": "import a from \"a.bk\"\nimport c from \"c.bk\"\nmain:(): {\n    b :: x\n}\n",
+				"a.bk":    "a :: y\n",
+			},
+			[]string{"could not find file c.bk", "undefined: x", "undefined: y"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+
+			_, _, err := NewClient().Load(filepath.Join(dir, "main.bk"))
+			errs := errors.Errors(err)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Load() errors = %v, want %v", errs, tt.want)
+			}
+
+			for i, err := range errs {
+				if berr, ok := err.(*bear.Error); !ok || !berr.HasLabel(tt.want[i]) {
+					t.Errorf("Load() error %d = %v, want %v", i, err, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClient_Load_warnings(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.bk": "import add from \"math.bk\"\nmain:(): {\n    a :: 1\n}\n",
+		"math.bk": "import echo\nadd:(a, b:int)<int>: {\n    return a + b\n}\n",
+	})
+
+	_, warnings, err := NewClient().Load(filepath.Join(dir, "main.bk"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// warnings are found before the names in math.bk are prefixed
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.Msg)
+	}
+	want := []string{"imported and not used: add", "declared and not used: a", "imported and not used: echo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() warnings = %q, want %q", got, want)
+	}
+}
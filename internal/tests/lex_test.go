@@ -18,7 +18,10 @@ func TestLexClient(t *testing.T) {
 				t.Fatalf("LexClient() unexpected error %v", err)
 			}
 
-			got := lex.NewClient().Lex(tokens)
+			got, err := lex.NewClient().Lex(tokens)
+			if err != nil {
+				t.Fatalf("LexClient() unexpected error %v", err)
+			}
 			if !reflect.DeepEqual(got, tt.wantLexs) {
 				t.Fatalf("LexClient() got and wanted tokens do not match\n%s", buildCompTable(got, tt.wantLexs))
 			}
@@ -18,6 +18,7 @@ type Token struct {
 // Client is a tokenizer client that tokenizes a file
 type Client struct {
 	sperators []rune
+	operators []string
 }
 
 // NewClient creates a new default Tok.Client
@@ -26,8 +27,12 @@ func NewClient() *Client {
 		sperators: []rune{
 			' ', '\t', '\n', // white space tokens
 			'(', ')', '{', '}', '[', ']', // parens etc. tokens
-			':', '.', ',', '$', '"', // punctuation tokens
-			'+', '-', '*', '/', '&', '|', '=', // math tokens
+			':', '.', ',', '$', '"', '#', ';', // punctuation tokens
+			'+', '-', '*', '/', '%', '^', '&', '|', '=', '<', '>', '!', // math tokens
+		},
+		operators: []string{
+			"::", "<-", "->", "++", "--", "**", "&&", "||", "<=", ">=", "==", "!=",
+			"+=", "-=", "*=", "/=", "%=",
 		},
 	}
 }
@@ -45,7 +50,9 @@ func (c *Client) Tokenize(fileName string) ([]Token, error) {
 	var collect []rune
 	var tokens []Token
 	var lineNumber, colNumber int
-	for _, r := range string(src) {
+	runes := []rune(string(src))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
 		if !c.split(r) {
 			collect = append(collect, r)
 		} else {
@@ -55,11 +62,20 @@ func (c *Client) Tokenize(fileName string) ([]Token, error) {
 				LineNumber: lineNumber,
 				ColNumber:  colNumber - len(collect),
 			})
+
+			// multi character operators are kept as a single token
+			value := string(r)
+			if i+1 < len(runes) && c.operator(runes[i:i+2]) {
+				value = string(runes[i : i+2])
+				i++
+				colNumber++
+			}
+
 			tokens = append(tokens, Token{
-				Value:      string(r),
+				Value:      value,
 				FileName:   fileName,
 				LineNumber: lineNumber,
-				ColNumber:  colNumber,
+				ColNumber:  colNumber - len(value) + 1,
 			})
 
 			collect = []rune{}
@@ -101,3 +117,14 @@ func (c *Client) split(check rune) bool {
 
 	return false
 }
+
+// operator returns true if the runes make up a multi character operator
+func (c *Client) operator(check []rune) bool {
+	for _, op := range c.operators {
+		if op == string(check) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -69,4 +69,15 @@ echo; echo "EXAMPLE 9"
 j=( 1 2 3 )
 j[1]=$(( j[1] + 1 ))
 j[0]=$(( ${j[0]} + ${j[2]} ))
-echo ${j[@]}
\ No newline at end of file
+echo ${j[@]}
+# Example 10
+# k :: [4, 5, 6]
+# l:[][]string: [["a", "b"], ["c"]]
+# n :: len(k) + len(l) + len(l[0])
+# nested arrays keep their length under the len key
+echo; echo "EXAMPLE 10"
+k=( 4 5 6 )
+declare -A l
+l=( [len]=2 [0,len]=2 [0,0]="a" [0,1]="b" [1,len]=1 [1,0]="c" )
+n=$(( ${#k[@]} + ${l[len]} + ${l[0,len]} ))
+echo $n
@@ -2,16 +2,17 @@
 
 # Example 1
 # import sed as test
-if [[ -z "$( which which )" ]]; then
+# import grep
+# every imported command is checked for at startup and gets its own exit code in import order
+if ! command -v sed > /dev/null; then
+    echo "imported command sed could not be found" >&2
     exit 213
 fi
-
-if [[ -z "$( which echo )" ]]; then
+if ! command -v grep > /dev/null; then
+    echo "imported command grep could not be found" >&2
     exit 214
 fi
 
 # rename will happen in translation
-if [[ -z "$( which sed )" ]]; then
-    echo "imported command sed could not be found"
-    exit 215
-fi
\ No newline at end of file
+# test["s/a/b/"]
+sed "s/a/b/"